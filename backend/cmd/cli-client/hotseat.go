@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"pandagame/internal/ai"
+	"pandagame/pkg/game"
+	"strconv"
+	"strings"
+)
+
+// a hot seat game runs entirely in this process. the players share the terminal and bots answer for the empty seats
+
+type seat struct {
+	player   game.Player
+	strategy string // empty for people
+}
+
+func hotSeats(names []string, bots int, strategy string) ([]seat, error) {
+	seats := make([]seat, 0, len(names)+bots)
+	for _, n := range names {
+		if n = strings.TrimSpace(n); n != "" {
+			seats = append(seats, seat{player: game.Player{ID: n, Name: n}})
+		}
+	}
+	for i := range bots {
+		name := fmt.Sprintf("bot%d", i+1)
+		seats = append(seats, seat{player: game.Player{ID: name, Name: name}, strategy: strategy})
+	}
+	if len(seats) < 2 || len(seats) > game.MaxPlayers {
+		return nil, fmt.Errorf("a game needs 2 to %d players", game.MaxPlayers)
+	}
+	if bots > 0 {
+		if _, err := ai.New(strategy); err != nil {
+			return nil, err
+		}
+	}
+	for i := range seats {
+		seats[i].player.Order = i + 1
+	}
+	return seats, nil
+}
+
+func playHotSeat(r io.Reader, w io.Writer, seats []seat, seed uint64) error {
+	players := make([]game.Player, len(seats))
+	bots := make(map[string]ai.Strategy)
+	for i, s := range seats {
+		players[i] = s.player
+		if s.strategy != "" {
+			bots[s.player.ID], _ = ai.NewSeeded(s.strategy, seed+uint64(i))
+		}
+	}
+	lines := bufio.NewScanner(r)
+
+	g := game.StartGame(players, game.WithSeed(seed))
+	fmt.Fprintf(w, "seed %d\n", seed)
+	prompt := game.GameFlow(g, game.PromptResponse{Action: game.NextPlayerTurn})
+	logged := 0
+	var turn game.TurnCounter
+	for prompt.Action != game.EndGame {
+		// legal responses are in the same order as the prompt's options
+		legal := g.LegalResponses()
+		if len(legal) == 0 {
+			return fmt.Errorf("nobody can answer %s for %s", prompt.Action, g.CurrentTurn.PlayerID)
+		}
+		// when there's only one thing to do, like rolling the die, it's submitted without asking
+		response := legal[0]
+		if bot, ok := bots[g.CurrentTurn.PlayerID]; ok && len(legal) > 1 {
+			response = bot.Choose(g)
+			if err := g.ValidatePlayerAction(response); err != nil {
+				// a bot that keeps giving the same rejected answer would never finish the game
+				fmt.Fprintf(w, "%s's answer was rejected (%s), so the first option is played\n", g.CurrentTurn.PlayerID, err.Message)
+				response = legal[0]
+			}
+		} else if len(legal) > 1 {
+			choice, ok := askHotSeat(lines, w, g, prompt, turn != g.TurnCounter)
+			turn = g.TurnCounter
+			if !ok {
+				return nil
+			}
+			response = legal[choice]
+		}
+
+		player := g.GetCurrentPlayer()
+		pid, name, completed := player.ID, player.Name, len(player.CompleteObjectives)
+		asked := prompt
+		entries := len(g.Journal.Entries)
+		prompt = game.GameFlow(g, response)
+		if len(g.Journal.Entries) == entries {
+			return fmt.Errorf("%s's answer to %s was not accepted", name, response.Action)
+		}
+		fmt.Fprintf(w, "%s: %s\n", name, describeMove(asked, g.Board, response))
+		for _, o := range g.GetPlayer(pid).CompleteObjectives[completed:] {
+			fmt.Fprintf(w, "%s completed %s\n", name, describeObjective(o))
+		}
+		for _, m := range g.GameLog[logged:] {
+			fmt.Fprintln(w, m.Message)
+		}
+		logged = len(g.GameLog)
+	}
+	renderResults(w, g.Results())
+	return nil
+}
+
+// what a player did, with the board as they left it
+func describeMove(p game.Prompt, b *game.Board, r game.PromptResponse) string {
+	label := optionLabel(p, b, r.Selection)
+	if r.Selection == nil || label == string(r.Action) {
+		return string(r.Action)
+	}
+	return fmt.Sprintf("%s %s", r.Action, label)
+}
+
+// shows the current player what they can see of the game and reads their choice. the whole state is shown
+// when their turn begins, and only the board for the rest of it. false when the input ends or they quit
+func askHotSeat(lines *bufio.Scanner, w io.Writer, g *game.GameState, prompt game.Prompt, newTurn bool) (int, bool) {
+	player := g.GetCurrentPlayer()
+	// nothing expires in a hot seat game
+	prompt.Time = 0
+	if newTurn {
+		fmt.Fprintf(w, "\n-- %s --", player.Name)
+		renderGameState(w, g.ClientSafe(player.ID).(game.ClientGameState))
+	} else {
+		fmt.Fprintln(w)
+		renderBoard(w, g.Board)
+	}
+	renderPrompt(w, prompt, g.Board)
+	for lines.Scan() {
+		line := strings.TrimSpace(lines.Text())
+		switch line {
+		case "quit":
+			return 0, false
+		case "board":
+			renderGameState(w, g.ClientSafe(player.ID).(game.ClientGameState))
+			renderPrompt(w, prompt, g.Board)
+			continue
+		}
+		choice, err := strconv.Atoi(line)
+		if err != nil || choice < 1 || choice > len(prompt.SelectFrom) {
+			fmt.Fprintf(w, "choose a number from 1 to %d\n", len(prompt.SelectFrom))
+			continue
+		}
+		return choice - 1, true
+	}
+	return 0, false
+}
@@ -1,13 +1,247 @@
 package main
 
-import "pandagame/internal/game"
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"os"
+	"pandagame/internal/ai"
+	"pandagame/pkg/client"
+	"pandagame/pkg/game"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// a terminal client for playtesting against a running panda-game server
 
 type clientState struct {
 	inGame      bool
 	gameStarted bool
-	gameState   game.GameState
+	me          string
+	lobby       game.Lobby
+	gameState   game.ClientGameState
+	prompt      *game.Prompt // the prompt waiting for an answer, if there is one
+	// handlers run on the connection's goroutine while input is read on main's
+	lock sync.Mutex
 }
 
 func main() {
+	addr := flag.String("addr", "localhost:3000", "address of the panda-game server")
+	username := flag.String("user", "", "username to sign in with")
+	password := flag.String("password", "", "password to sign in with")
+	join := flag.String("join", "", "id of the game to join. a new game is created when empty")
+	rejoin := flag.String("rejoin", "", "id of a game you were disconnected from, to pick up where you left off")
+	verbose := flag.Bool("v", false, "show log output")
+	hotseat := flag.String("hotseat", "", "comma separated names of players sharing this terminal. plays without a server")
+	bots := flag.Int("bots", 0, "number of bots to add to a hot seat game")
+	strategy := flag.String("strategy", ai.GreedyStrategy, "how hot seat bots play: random, greedy or lookahead")
+	seed := flag.Uint64("seed", 0, "seed for a hot seat game. a random seed is chosen when 0")
+	flag.Parse()
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+
+	if *hotseat != "" || *bots > 0 {
+		seats, err := hotSeats(strings.Split(*hotseat, ","), *bots, *strategy)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if *seed == 0 {
+			*seed = rand.Uint64()
+		}
+		if err := playHotSeat(os.Stdin, os.Stdout, seats, *seed); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	token, err := client.Login(*addr, *username, *password)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "sign in failed:", err)
+		os.Exit(1)
+	}
+	c, err := client.Dial(*addr, token)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "could not connect:", err)
+		os.Exit(1)
+	}
+	defer c.Close()
+
+	state := &clientState{me: c.ID()}
+	c.Configure(state.handlers(os.Stdout, c))
+	switch {
+	case *rejoin != "":
+		err = c.Reprompt(*rejoin)
+	case *join != "":
+		err = c.JoinGame(*join)
+	default:
+		err = c.CreateGame()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "could not reach the game:", err)
+		os.Exit(1)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := c.Run(); err != nil {
+			fmt.Fprintln(os.Stderr, "disconnected:", err)
+		}
+	}()
+	lines := make(chan string)
+	go readLines(os.Stdin, lines)
+
+	for {
+		select {
+		case <-done:
+			return
+		case line, ok := <-lines:
+			if !ok {
+				c.LeaveGame(state.gameId())
+				return
+			}
+			quit, err := state.handleInput(os.Stdout, c, strings.TrimSpace(line))
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "could not send:", err)
+				return
+			}
+			if quit {
+				return
+			}
+		}
+	}
+}
+
+func readLines(r io.Reader, lines chan<- string) {
+	defer close(lines)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lines <- scanner.Text()
+	}
+}
+
+func (s *clientState) gameId() string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.lobby.GameId
+}
+
+// keeps the state up to date with what the server sends and shows it. the connection is closed when the game is over
+func (s *clientState) handlers(w io.Writer, c *client.Client) func(*client.Handlers) {
+	return func(h *client.Handlers) {
+		h.LobbyUpdate = func(l game.Lobby) {
+			s.lock.Lock()
+			defer s.lock.Unlock()
+			s.inGame = true
+			s.lobby = l
+			renderLobby(w, l, s.me)
+		}
+		h.GameStart = s.update(w)
+		h.GameUpdate = s.update(w)
+		h.ActionPrompt = func(p game.Prompt) {
+			s.lock.Lock()
+			defer s.lock.Unlock()
+			s.prompt = &p
+			renderPrompt(w, p, s.gameState.Board)
+		}
+		h.GameOver = func(r game.GameResults) {
+			s.lock.Lock()
+			s.prompt = nil
+			s.lock.Unlock()
+			renderResults(w, r)
+			c.Close()
+		}
+		h.ChatMessage = func(m game.ChatMessage) {
+			renderChat(w, m)
+		}
+		h.ChatHistory = func(p game.ChatPage) {
+			for _, m := range p.Messages {
+				renderChat(w, m)
+			}
+			if p.More {
+				fmt.Fprintln(w, "(older messages not shown)")
+			}
+		}
+		h.Warning = func(v game.ValidationError) {
+			fmt.Fprintf(w, "warning: %s (%s)\n", v.Message, v.Code)
+		}
+		h.Goodbye = func() {
+			fmt.Fprintln(w, "the server closed the connection")
+			c.Close()
+		}
+	}
+}
+
+func (s *clientState) update(w io.Writer) func(game.ClientGameState) {
+	return func(g game.ClientGameState) {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		s.gameStarted = true
+		s.gameState = g
+		// the prompt was answered by someone else, auto-played after it expired or replaced by a newer one
+		if s.prompt != nil && (!g.Turn.YourTurn || g.Turn.Prompt.Pid != s.prompt.Pid) {
+			s.prompt = nil
+		}
+		renderGameState(w, g)
+		if s.prompt != nil {
+			renderPrompt(w, *s.prompt, s.gameState.Board)
+		}
+	}
+}
 
+// answers the current prompt with a numbered choice, or runs one of the commands. returns true when the player quits
+func (s *clientState) handleInput(w io.Writer, c *client.Client, line string) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if text, ok := strings.CutPrefix(line, "say "); ok {
+		return false, c.Chat(s.lobby.GameId, text)
+	}
+	switch line {
+	case "":
+		return false, nil
+	case "chat":
+		return false, c.FetchChat(s.lobby.GameId, 0, 0)
+	case "start":
+		if s.gameStarted || s.lobby.Host != s.me {
+			fmt.Fprintln(w, "only the host can start the game, before it has begun")
+			return false, nil
+		}
+		return false, c.StartGame(s.lobby.GameId)
+	case "bot", "bot " + ai.RandomStrategy, "bot " + ai.GreedyStrategy, "bot " + ai.LookaheadStrategy:
+		if s.gameStarted || s.lobby.Host != s.me {
+			fmt.Fprintln(w, "only the host can add bots, before the game has begun")
+			return false, nil
+		}
+		strategy := strings.TrimSpace(strings.TrimPrefix(line, "bot"))
+		if strategy == "" {
+			strategy = ai.GreedyStrategy
+		}
+		return false, c.AddBot(s.lobby.GameId, strategy)
+	case "board":
+		if s.gameStarted {
+			renderGameState(w, s.gameState)
+		}
+		return false, nil
+	case "quit":
+		return true, c.LeaveGame(s.lobby.GameId)
+	}
+	if s.prompt == nil {
+		fmt.Fprintln(w, "nothing to answer yet. commands: start, bot [random|greedy|lookahead], board, say <message>, chat, quit")
+		return false, nil
+	}
+	choice, err := strconv.Atoi(line)
+	if err != nil || choice < 1 || choice > len(s.prompt.SelectFrom) {
+		fmt.Fprintf(w, "choose a number from 1 to %d\n", len(s.prompt.SelectFrom))
+		return false, nil
+	}
+	p := *s.prompt
+	s.prompt = nil
+	return false, c.Respond(p, p.SelectFrom[choice-1])
 }
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"pandagame/pkg/game"
+	"slices"
+	"strings"
+)
+
+// each hex on the board is drawn cellWidth characters wide. hexes in neighboring rows are offset by half a cell
+const cellWidth = 8
+
+var plotLetters = map[game.PlotType]string{
+	game.GreenBambooPlot:  "G",
+	game.YellowBambooPlot: "Y",
+	game.PinkBambooPlot:   "P",
+}
+
+var improvementLetters = map[game.ImprovementType]string{
+	game.WatershedImprovement:  "w",
+	game.EnclosureImprovement:  "e",
+	game.FertilizerImprovement: "f",
+}
+
+// a short description of what is on a plot: color, bamboo height, improvement, irrigation, and which pieces are there
+func plotContents(b *game.Board, p game.Plot) string {
+	var s string
+	switch {
+	case p.Type == game.PondPlot:
+		s = "~~"
+	case p.Type == game.FuturePlot:
+		return ".."
+	default:
+		s = fmt.Sprintf("%s%d%s", plotLetters[p.Type], p.Bamboo, improvementLetters[p.Improvement.Type])
+		if b.PlotIsIrrigated(p.ID) {
+			s += "~"
+		}
+	}
+	if b.PandaLocation == p.ID {
+		s += "@"
+	}
+	if b.GardenerLocation == p.ID {
+		s += "&"
+	}
+	return s
+}
+
+// draws the board as rows of hexes using the plots' coordinates. every hex is two lines, its id above its contents
+func renderBoard(w io.Writer, b *game.Board) {
+	if b == nil || len(b.Plots) == 0 {
+		return
+	}
+	minCol, maxCol := math.MaxInt, math.MinInt
+	minRow, maxRow := math.MaxInt, math.MinInt
+	rows := make(map[int][]game.Plot)
+	for _, p := range b.Plots {
+		col := 2*p.Coord.Q + p.Coord.R
+		minCol, maxCol = min(minCol, col), max(maxCol, col)
+		minRow, maxRow = min(minRow, p.Coord.R), max(maxRow, p.Coord.R)
+		rows[p.Coord.R] = append(rows[p.Coord.R], p)
+	}
+	width := (maxCol-minCol)*cellWidth/2 + cellWidth
+	for r := minRow; r <= maxRow; r++ {
+		ids := []byte(strings.Repeat(" ", width))
+		contents := []byte(strings.Repeat(" ", width))
+		for _, p := range rows[r] {
+			x := (2*p.Coord.Q + p.Coord.R - minCol) * cellWidth / 2
+			copy(ids[x:], p.ID)
+			copy(contents[x:], plotContents(b, p))
+		}
+		fmt.Fprintln(w, strings.TrimRight(string(ids), " "))
+		fmt.Fprintln(w, strings.TrimRight(string(contents), " "))
+	}
+	fmt.Fprintln(w, "G/Y/P color and height, w/e/f improvement, ~ irrigated, @ panda, & gardener, .. open space")
+}
+
+func describeReserve[T ~string](r map[T]int) string {
+	keys := make([]string, 0, len(r))
+	for k, v := range r {
+		if v > 0 {
+			keys = append(keys, fmt.Sprintf("%s:%d", k, v))
+		}
+	}
+	if len(keys) == 0 {
+		return "none"
+	}
+	slices.Sort(keys)
+	return strings.Join(keys, " ")
+}
+
+func describeObjective(o game.Objective) string {
+	switch ob := o.ObjectiveChecker.(type) {
+	case game.PandaObjective:
+		return fmt.Sprintf("panda: eat %d green, %d yellow, %d pink (%d pts)", ob.GreenCount, ob.YellowCount, ob.PinkCount, ob.Value)
+	case game.GardenerObjective:
+		s := fmt.Sprintf("gardener: %d %s shoot(s) of height %d", ob.Count, ob.Color, ob.Height)
+		if ob.Improvement != "" && ob.Improvement != game.AnyImprovement {
+			s += fmt.Sprintf(" with %s", ob.Improvement)
+		}
+		return s + fmt.Sprintf(" (%d pts)", ob.Value)
+	case game.PlotObjective:
+		neighbors := make([]string, 0, len(ob.Neighbors))
+		for _, n := range ob.Neighbors {
+			if n != "" {
+				neighbors = append(neighbors, string(n))
+			}
+		}
+		return fmt.Sprintf("plot: %s next to %s (%d pts)", ob.AnchorColor, strings.Join(neighbors, ", "), ob.Value)
+	case game.EmperorObjective:
+		return fmt.Sprintf("emperor (%d pts)", ob.Points())
+	default:
+		return "unknown objective"
+	}
+}
+
+func renderLobby(w io.Writer, l game.Lobby, me string) {
+	fmt.Fprintf(w, "\n== lobby %s ==\n", l.GameId)
+	for _, p := range l.Players {
+		tags := ""
+		if p == l.Host {
+			tags += " (host)"
+		}
+		if p == me {
+			tags += " (you)"
+		}
+		if strategy, ok := l.Bots[p]; ok {
+			tags += fmt.Sprintf(" (%s bot)", strategy)
+		}
+		fmt.Fprintf(w, "  %s%s\n", p, tags)
+	}
+	if l.Settings.TimeBanks() {
+		fmt.Fprintf(w, "time bank: %ds +%ds per turn, on expiry: %s\n", l.Settings.TimeBank, l.Settings.Increment, l.Settings.BankExpiry)
+	}
+	if l.Settings.OnLeave == game.BotOnLeave {
+		fmt.Fprintln(w, "a bot takes over the seat of anyone who leaves")
+	}
+	if me == l.Host && !l.Started {
+		fmt.Fprintln(w, "type 'start' to start the game, or 'bot [random|greedy|lookahead]' to fill a seat")
+	}
+}
+
+func renderGameState(w io.Writer, g game.ClientGameState) {
+	fmt.Fprintf(w, "\n== round %d ==\n", g.TurnCounter.Round)
+	renderBoard(w, g.Board)
+	fmt.Fprintf(w, "plots left: %d, irrigation: %d, improvements: %s\n", g.PlotDeckHeight, g.IrrigationReserve, describeReserve(g.AvailableImprovements))
+	if g.BambooSupply != nil {
+		fmt.Fprintf(w, "bamboo supply: %s\n", describeReserve(g.BambooSupply))
+	}
+	for i, p := range g.Players {
+		status := ""
+		if g.TurnCounter.Position == i {
+			status = " <- turn"
+		}
+		if p.Forfeited {
+			status = " (forfeited)"
+		}
+		points := 0
+		for _, o := range p.CompleteObjectives {
+			points += o.Points()
+		}
+		fmt.Fprintf(w, "%s%s: %d pts, irrigation %d, bamboo %s, improvements %s", p.Name, status, points, p.Irrigations, describeReserve(p.Bamboo), describeReserve(p.Improvements))
+		if g.Settings.TimeBanks() {
+			fmt.Fprintf(w, ", %ds left", p.TimeBank)
+		}
+		fmt.Fprintln(w)
+		if len(p.Objectives) > 0 {
+			for _, o := range p.Objectives {
+				fmt.Fprintf(w, "    %s\n", describeObjective(o))
+			}
+		} else if len(p.HiddenObjectives) > 0 {
+			fmt.Fprintf(w, "    objectives: %s\n", describeReserve(p.HiddenObjectives))
+		}
+	}
+	if g.Turn.Weather != "" && g.Turn.Weather != game.NoWeather {
+		fmt.Fprintf(w, "weather: %s\n", g.Turn.Weather)
+	}
+}
+
+// the text for one of a prompt's options
+func optionLabel(p game.Prompt, b *game.Board, option any) string {
+	switch p.SelectType {
+	case game.PlotSelectType:
+		dp := game.GetSelection(game.ChoosePlot, option).(game.DeckPlot)
+		if dp.Improvement == "" || dp.Improvement == game.NoImprovement {
+			return string(dp.Type)
+		}
+		return fmt.Sprintf("%s with %s", dp.Type, dp.Improvement)
+	case game.EdgeIDSelectType:
+		id := fmt.Sprint(option)
+		if b == nil {
+			return id
+		}
+		e := b.Edges[id]
+		return fmt.Sprintf("%s (between %s and %s)", id, e.Plots[0], e.Plots[1])
+	case game.PlotIDSelectType:
+		id := fmt.Sprint(option)
+		if b == nil {
+			return id
+		}
+		return fmt.Sprintf("%s [%s]", id, plotContents(b, b.Plots[id]))
+	default:
+		return fmt.Sprint(option)
+	}
+}
+
+func renderPrompt(w io.Writer, p game.Prompt, b *game.Board) {
+	fmt.Fprintf(w, "\n%s", p.Action)
+	if p.Time > 0 {
+		fmt.Fprintf(w, " (%ds)", p.Time)
+	}
+	fmt.Fprintln(w)
+	for i, o := range p.SelectFrom {
+		fmt.Fprintf(w, "  %d) %s\n", i+1, optionLabel(p, b, o))
+	}
+}
+
+func renderChat(w io.Writer, m game.ChatMessage) {
+	from := m.From
+	if m.Spectator {
+		from += " (spectating)"
+	}
+	fmt.Fprintf(w, "[%s] %s: %s\n", m.Timestamp.Local().Format("15:04"), from, m.Message)
+}
+
+func renderResults(w io.Writer, r game.GameResults) {
+	fmt.Fprintln(w, "\n== game over ==")
+	for _, s := range r.Standings {
+		name := s.Name
+		if name == "" {
+			name = s.PlayerID
+		}
+		fmt.Fprintf(w, "%d. %s: %d pts (%d from pandas, %d objectives)", s.Rank, name, s.Score, s.PandaScore, s.ObjectivesCompleted)
+		if s.Forfeited {
+			fmt.Fprint(w, " forfeited")
+		}
+		fmt.Fprintln(w)
+	}
+}
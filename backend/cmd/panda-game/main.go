@@ -16,7 +16,9 @@ import (
 func main() {
 	config.SetLogger("panda-server.log")
 	appConfig := config.LoadAppConfig()
-	fw := framework.NewFramework(new(engine.PandaGameEngine))
+	pandaEngine := engine.NewPandaGameEngine()
+	fw := framework.NewFramework(pandaEngine)
+	pandaEngine.SetDispatcher(fw.Dispatch)
 	fw.Configure(func(fc *framework.FrameworkConfig) {
 		fc.Groups = scaling.Grouper(appConfig)
 		fc.Relayer = scaling.Relayer(appConfig)
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"pandagame/internal/ai"
+	"pandagame/pkg/game"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// plays games between computer players without a server and reports how they went, for tuning decks and house rules
+
+// games that run longer than this are stopped and counted as unfinished
+const maxResponses = 5000
+
+// what happened in one game
+type outcome struct {
+	finished bool
+	rounds   int
+	results  game.GameResults
+	// the strategy playing each player id, and the ids in turn order
+	strategies map[string]string
+	order      []string
+	rolled     map[game.WeatherType]int
+	chosen     map[game.WeatherType]int
+	// objectives by card, counted once per player who drew it
+	drawn     map[string]int
+	completed map[string]int
+}
+
+func main() {
+	games := flag.Int("games", 1000, "number of games to play")
+	seats := flag.String("strategies", "greedy,random", "comma separated strategy for each seat: random, greedy or lookahead")
+	seed := flag.Uint64("seed", 1, "seed of the first game. game i uses seed+i, so a run can be repeated")
+	format := flag.String("format", "json", "report format: json or csv")
+	settingsFile := flag.String("settings", "", "json file of game settings to play with, for trying house rules")
+	workers := flag.Int("workers", runtime.NumCPU(), "games to play at the same time")
+	flag.Parse()
+	log.SetOutput(io.Discard)
+
+	strategies := strings.Split(*seats, ",")
+	if len(strategies) < 2 || len(strategies) > game.MaxPlayers {
+		fail(fmt.Errorf("a game needs 2 to %d strategies", game.MaxPlayers))
+	}
+	for _, s := range strategies {
+		if _, err := ai.New(s); err != nil {
+			fail(err)
+		}
+	}
+	settings := game.DefaultSettings()
+	if *settingsFile != "" {
+		b, err := os.ReadFile(*settingsFile)
+		if err != nil {
+			fail(err)
+		}
+		if err := json.Unmarshal(b, &settings); err != nil {
+			fail(err)
+		}
+		if err := settings.Validate(); err != nil {
+			fail(err)
+		}
+	}
+	if *format != "json" && *format != "csv" {
+		fail(fmt.Errorf("unknown format: %s", *format))
+	}
+
+	seeds := make(chan uint64)
+	outcomes := make(chan outcome)
+	var wg sync.WaitGroup
+	for range max(*workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for s := range seeds {
+				outcomes <- play(strategies, settings, s)
+			}
+		}()
+	}
+	go func() {
+		for i := range *games {
+			seeds <- *seed + uint64(i)
+		}
+		close(seeds)
+		wg.Wait()
+		close(outcomes)
+	}()
+
+	r := newReport(strategies, *seed)
+	for o := range outcomes {
+		r.add(o)
+	}
+	r.finish()
+	var err error
+	if *format == "csv" {
+		err = r.writeCSV(os.Stdout)
+	} else {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(r)
+	}
+	if err != nil {
+		fail(err)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
+
+// plays one game with a seat for each strategy
+func play(strategies []string, settings game.Settings, seed uint64) outcome {
+	o := outcome{
+		strategies: make(map[string]string),
+		rolled:     make(map[game.WeatherType]int),
+		chosen:     make(map[game.WeatherType]int),
+		drawn:      make(map[string]int),
+		completed:  make(map[string]int),
+	}
+	players := make([]game.Player, len(strategies))
+	bots := make(map[string]ai.Strategy)
+	for i, name := range strategies {
+		id := fmt.Sprintf("seat%d", i+1)
+		players[i] = game.Player{ID: id, Name: id, Order: i + 1}
+		o.strategies[id] = name
+		bots[id], _ = ai.NewSeeded(name, seed+uint64(i))
+	}
+	g := game.StartGame(players, game.WithSeed(seed), game.WithSettings(settings))
+	for _, p := range g.Players {
+		o.order = append(o.order, p.ID)
+	}
+
+	prompt := game.GameFlow(g, game.PromptResponse{Action: game.NextPlayerTurn})
+	for range maxResponses {
+		if prompt.Action == game.EndGame {
+			o.finished = true
+			break
+		}
+		response := bots[g.CurrentTurn.PlayerID].Choose(g)
+		prompt = game.GameFlow(g, response)
+		switch response.Action {
+		case game.RollDie:
+			if prompt.Action == game.ChooseWeather {
+				o.rolled[game.ChoiceWeather]++
+			} else {
+				o.rolled[g.CurrentTurn.Weather]++
+			}
+		case game.ChooseWeather:
+			o.chosen[g.CurrentTurn.Weather]++
+		}
+	}
+
+	if !o.finished {
+		fmt.Fprintf(os.Stderr, "game %d was stopped after %d responses in round %d, waiting on %s\n", seed, maxResponses, g.TurnCounter.Round, prompt.Action)
+	}
+	o.rounds = g.TurnCounter.Round
+	o.results = g.Results()
+	for _, p := range g.Players {
+		for _, ob := range p.Objectives {
+			o.drawn[cardKey(ob)]++
+		}
+		for _, ob := range p.CompleteObjectives {
+			if ob.Type() == game.EmperorObjectiveType {
+				continue
+			}
+			o.drawn[cardKey(ob)]++
+			o.completed[cardKey(ob)]++
+		}
+	}
+	return o
+}
+
+// identical cards share a key: the card's json
+func cardKey(o game.Objective) string {
+	b, _ := json.Marshal(&o)
+	return string(b)
+}
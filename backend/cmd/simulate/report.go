@@ -0,0 +1,232 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"math"
+	"pandagame/pkg/game"
+	"slices"
+	"strconv"
+)
+
+type distribution struct {
+	Mean      float64     `json:"mean"`
+	StdDev    float64     `json:"stdDev"`
+	Min       int         `json:"min"`
+	Max       int         `json:"max"`
+	Histogram map[int]int `json:"histogram"`
+	values    []int
+}
+
+func (d *distribution) add(v int) {
+	d.values = append(d.values, v)
+}
+
+func (d *distribution) finish() {
+	d.Histogram = make(map[int]int)
+	if len(d.values) == 0 {
+		return
+	}
+	d.Min, d.Max = slices.Min(d.values), slices.Max(d.values)
+	sum := 0
+	for _, v := range d.values {
+		sum += v
+		d.Histogram[v]++
+	}
+	d.Mean = float64(sum) / float64(len(d.values))
+	variance := 0.0
+	for _, v := range d.values {
+		variance += (float64(v) - d.Mean) * (float64(v) - d.Mean)
+	}
+	d.StdDev = math.Sqrt(variance / float64(len(d.values)))
+}
+
+type strategyReport struct {
+	Seats   int           `json:"seats"` // how many times the strategy sat down to play
+	Wins    int           `json:"wins"`  // shared victories count as a win for every winner
+	WinRate float64       `json:"winRate"`
+	Scores  *distribution `json:"scores"`
+}
+
+// how the players that took their first turn at this position did, to show any first player advantage
+type positionReport struct {
+	Position int           `json:"position"`
+	Wins     int           `json:"wins"`
+	WinRate  float64       `json:"winRate"`
+	Scores   *distribution `json:"scores"`
+}
+
+type cardReport struct {
+	Card           json.RawMessage `json:"card"`
+	Type           string          `json:"type"`
+	Drawn          int             `json:"drawn"`
+	Completed      int             `json:"completed"`
+	CompletionRate float64         `json:"completionRate"`
+}
+
+type report struct {
+	Games         int                        `json:"games"`
+	Unfinished    int                        `json:"unfinished"` // games stopped before they ended. they are left out of everything else
+	FirstSeed     uint64                     `json:"firstSeed"`
+	Seats         []string                   `json:"seats"`
+	Strategies    map[string]*strategyReport `json:"strategies"`
+	TurnOrder     []*positionReport          `json:"turnOrder"`
+	Rounds        *distribution              `json:"rounds"`
+	RolledWeather map[game.WeatherType]int   `json:"rolledWeather"`
+	ChosenWeather map[game.WeatherType]int   `json:"chosenWeather"` // what players picked when they rolled CHOICE
+	Objectives    []*cardReport              `json:"objectives"`
+	cards         map[string]*cardReport
+}
+
+func newReport(seats []string, seed uint64) *report {
+	r := &report{
+		FirstSeed:     seed,
+		Seats:         seats,
+		Strategies:    make(map[string]*strategyReport),
+		Rounds:        &distribution{},
+		RolledWeather: make(map[game.WeatherType]int),
+		ChosenWeather: make(map[game.WeatherType]int),
+		cards:         make(map[string]*cardReport),
+	}
+	for _, s := range seats {
+		r.Strategies[s] = &strategyReport{Scores: &distribution{}}
+	}
+	for i := range seats {
+		r.TurnOrder = append(r.TurnOrder, &positionReport{Position: i + 1, Scores: &distribution{}})
+	}
+	// every card in the decks is reported, even if it is never drawn
+	for ot, deck := range game.NewGame().ObjectiveDecks {
+		for _, o := range deck {
+			key := cardKey(o)
+			if _, ok := r.cards[key]; !ok {
+				r.cards[key] = &cardReport{Card: json.RawMessage(key), Type: string(ot)}
+			}
+		}
+	}
+	return r
+}
+
+func (r *report) add(o outcome) {
+	r.Games++
+	if !o.finished {
+		r.Unfinished++
+		return
+	}
+	r.Rounds.add(o.rounds)
+	for w, n := range o.rolled {
+		r.RolledWeather[w] += n
+	}
+	for w, n := range o.chosen {
+		r.ChosenWeather[w] += n
+	}
+	for _, s := range o.results.Standings {
+		won := slices.Contains(o.results.Winners, s.PlayerID)
+		sr := r.Strategies[o.strategies[s.PlayerID]]
+		sr.Seats++
+		sr.Scores.add(s.Score)
+		pr := r.TurnOrder[slices.Index(o.order, s.PlayerID)]
+		pr.Scores.add(s.Score)
+		if won {
+			sr.Wins++
+			pr.Wins++
+		}
+	}
+	for key, n := range o.drawn {
+		c, ok := r.cards[key]
+		if !ok {
+			c = &cardReport{Card: json.RawMessage(key)}
+			r.cards[key] = c
+		}
+		c.Drawn += n
+		c.Completed += o.completed[key]
+	}
+}
+
+func (r *report) finish() {
+	r.Rounds.finish()
+	for _, sr := range r.Strategies {
+		sr.Scores.finish()
+		sr.WinRate = rate(sr.Wins, sr.Seats)
+	}
+	for _, pr := range r.TurnOrder {
+		pr.Scores.finish()
+		pr.WinRate = rate(pr.Wins, len(pr.Scores.values))
+	}
+	for _, c := range r.cards {
+		c.CompletionRate = rate(c.Completed, c.Drawn)
+		r.Objectives = append(r.Objectives, c)
+	}
+	slices.SortFunc(r.Objectives, func(a, b *cardReport) int {
+		if a.Type != b.Type {
+			if a.Type < b.Type {
+				return -1
+			}
+			return 1
+		}
+		if a.CompletionRate != b.CompletionRate {
+			if a.CompletionRate > b.CompletionRate {
+				return -1
+			}
+			return 1
+		}
+		return slices.Compare(a.Card, b.Card)
+	})
+}
+
+func rate(n, of int) float64 {
+	if of == 0 {
+		return 0
+	}
+	return float64(n) / float64(of)
+}
+
+// writes the report as rows of section, key, metric and value, which spreadsheets can pivot
+func (r *report) writeCSV(w io.Writer) error {
+	out := csv.NewWriter(w)
+	row := func(section, key, metric string, value float64) {
+		out.Write([]string{section, key, metric, strconv.FormatFloat(value, 'f', -1, 64)})
+	}
+	dist := func(section, key string, d *distribution) {
+		row(section, key, "mean", d.Mean)
+		row(section, key, "stdDev", d.StdDev)
+		row(section, key, "min", float64(d.Min))
+		row(section, key, "max", float64(d.Max))
+	}
+	out.Write([]string{"section", "key", "metric", "value"})
+	row("games", "", "played", float64(r.Games))
+	row("games", "", "unfinished", float64(r.Unfinished))
+	dist("rounds", "", r.Rounds)
+	for _, s := range sortedKeys(r.Strategies) {
+		sr := r.Strategies[s]
+		row("strategy", s, "seats", float64(sr.Seats))
+		row("strategy", s, "winRate", sr.WinRate)
+		dist("strategy", s, sr.Scores)
+	}
+	for _, pr := range r.TurnOrder {
+		key := strconv.Itoa(pr.Position)
+		row("turnOrder", key, "winRate", pr.WinRate)
+		dist("turnOrder", key, pr.Scores)
+	}
+	for _, w := range sortedKeys(r.RolledWeather) {
+		row("rolledWeather", string(w), "count", float64(r.RolledWeather[w]))
+	}
+	for _, w := range sortedKeys(r.ChosenWeather) {
+		row("chosenWeather", string(w), "count", float64(r.ChosenWeather[w]))
+	}
+	for _, c := range r.Objectives {
+		row("objective", string(c.Card), "drawn", float64(c.Drawn))
+		row("objective", string(c.Card), "completionRate", c.CompletionRate)
+	}
+	out.Flush()
+	return out.Error()
+}
+
+func sortedKeys[K ~string, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,137 @@
+package ai
+
+import (
+	"math/rand/v2"
+	"pandagame/pkg/game"
+	"slices"
+)
+
+// an objective in hand is worth this share of its points before any progress is made on it,
+// so drawing objectives looks better than doing nothing
+const potential = 0.2
+
+// the most irrigation channels, or improvements, that count for anything while they are held
+const heldResources = 2
+
+// takes whichever response leaves the player closest to completing their objectives
+type Greedy struct {
+	r *rand.Rand
+}
+
+func (s *Greedy) Choose(g *game.GameState) game.PromptResponse {
+	pid := g.CurrentTurn.PlayerID
+	return best(g.LegalResponses(), s.r, func(response game.PromptResponse) float64 {
+		return evaluate(simulate(g, response, s.r), pid)
+	})
+}
+
+// how well the player is doing: the points they have scored, and a share of the points in their hand for the progress made on them
+func evaluate(g *game.GameState, pid string) float64 {
+	p := g.GetPlayer(pid)
+	if p == nil {
+		return 0
+	}
+	score := float64(p.Score())
+	for _, o := range p.Objectives {
+		score += float64(o.Points()) * (potential + (1-potential)*progress(o, *p, g.Board))
+	}
+	// resources are worth a little, since they become progress later. only a few are worth holding on to
+	score += 0.5 * float64(min(p.Irrigations, heldResources))
+	held := 0
+	for _, n := range p.Improvements {
+		held += n
+	}
+	score += 0.5 * float64(min(held, heldResources))
+	return score
+}
+
+// how close the objective is to complete, from 0 to 1
+func progress(o game.Objective, p game.Player, b *game.Board) float64 {
+	if o.IsComplete(p, *b) {
+		return 1
+	}
+	switch ob := o.ObjectiveChecker.(type) {
+	case game.PandaObjective:
+		return pandaProgress(ob, p)
+	case game.GardenerObjective:
+		return gardenerProgress(ob, b)
+	case game.PlotObjective:
+		return plotProgress(ob, b)
+	default:
+		return 0
+	}
+}
+
+// the share of the required bamboo the player has eaten
+func pandaProgress(o game.PandaObjective, p game.Player) float64 {
+	need := game.BambooReserve{game.GreenBambooPlot: o.GreenCount, game.YellowBambooPlot: o.YellowCount, game.PinkBambooPlot: o.PinkCount}
+	total, have := 0, 0
+	for color, n := range need {
+		total += n
+		have += min(p.Bamboo[color], n)
+	}
+	if total == 0 {
+		return 1
+	}
+	return float64(have) / float64(total)
+}
+
+// how tall the tallest matching shoots are compared to the height they need
+func gardenerProgress(o game.GardenerObjective, b *game.Board) float64 {
+	if o.Height == 0 || o.Count == 0 {
+		return 0
+	}
+	heights := make([]int, 0)
+	for _, plot := range b.Plots {
+		if plot.Type == o.Color && game.ImprovementTypeEqual(o.Improvement, plot.Improvement.Type) {
+			heights = append(heights, min(plot.Bamboo, o.Height))
+		}
+	}
+	slices.Sort(heights)
+	slices.Reverse(heights)
+	grown := 0
+	for _, h := range heights[:min(len(heights), o.Count)] {
+		grown += h
+	}
+	return float64(grown) / float64(o.Height*o.Count)
+}
+
+// the share of the pattern in place around the best anchor. neighbors that aren't irrigated yet count for half
+func plotProgress(o game.PlotObjective, b *game.Board) float64 {
+	required := 0
+	for _, n := range o.Neighbors {
+		if n != game.AnyPlot {
+			required++
+		}
+	}
+	top := 0.0
+	for _, anchor := range b.Plots {
+		if anchor.Type != o.AnchorColor {
+			continue
+		}
+		for persp := 0; persp < 6; persp++ {
+			matched := 0.0
+			for i, want := range o.Neighbors {
+				if want == game.AnyPlot {
+					continue
+				}
+				neighbor := b.PlotNeighbor(anchor.ID, persp+i)
+				if neighbor == nil || neighbor.Type != want {
+					continue
+				}
+				if b.PlotIsIrrigated(neighbor.ID) {
+					matched++
+				} else {
+					matched += 0.5
+				}
+			}
+			// the anchor itself is part of the pattern
+			matched++
+			if !b.PlotIsIrrigated(anchor.ID) {
+				matched -= 0.5
+			}
+			top = max(top, matched/float64(required+1))
+		}
+	}
+	return top
+}
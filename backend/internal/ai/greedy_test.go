@@ -0,0 +1,55 @@
+package ai
+
+import (
+	"pandagame/pkg/game"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// a game where player a is hungry for pink bamboo and the panda can reach some
+func hungryGame() *game.GameState {
+	g := game.NewGame(game.WithSeed(1))
+	g.AddPlayers([]game.Player{{
+		ID:           "a",
+		Bamboo:       make(game.BambooReserve),
+		Improvements: make(game.ImprovementReserve),
+		Objectives:   []game.Objective{{ObjectiveChecker: game.PandaObjective{PinkCount: 1, Value: 4, OT: game.PandaObjectiveType}}},
+	}})
+	g.NextTurn()
+	g.Board.AddPlot("p1", game.GreenBambooPlot, game.NoImprovement)
+	g.Board.AddPlot("p2", game.PinkBambooPlot, game.NoImprovement)
+	g.CurrentTurn.CurrentPrompt = game.Prompt{
+		Action:     game.ChoosePandaDestination,
+		SelectType: game.PlotIDSelectType,
+		SelectFrom: []any{"p1", "p2"},
+		Pid:        "prompt",
+	}
+	return g
+}
+
+func TestPandaProgress(t *testing.T) {
+	o := game.PandaObjective{GreenCount: 2, PinkCount: 2}
+	assert.Equal(t, 0.0, pandaProgress(o, game.Player{}))
+	assert.Equal(t, 0.5, pandaProgress(o, game.Player{Bamboo: game.BambooReserve{game.GreenBambooPlot: 3, game.YellowBambooPlot: 4}}))
+}
+
+func TestGardenerProgress(t *testing.T) {
+	g := hungryGame()
+	// the plots next to the pond grew their first shoot when they were placed
+	o := game.GardenerObjective{Color: game.PinkBambooPlot, Height: 4, Count: 1, Improvement: game.AnyImprovement}
+	assert.Equal(t, 0.25, gardenerProgress(o, g.Board))
+	o.Count = 2
+	assert.Equal(t, 0.125, gardenerProgress(o, g.Board))
+	o.Improvement = game.FertilizerImprovement
+	assert.Equal(t, 0.0, gardenerProgress(o, g.Board))
+}
+
+func TestGreedyEatsWhatItNeeds(t *testing.T) {
+	g := hungryGame()
+	s, _ := NewSeeded(GreedyStrategy, 1)
+	response := s.Choose(g)
+	assert.Equal(t, "p2", response.Selection)
+	game.GameFlow(g, response)
+	assert.Equal(t, 4, g.Players[0].Score())
+}
@@ -0,0 +1,36 @@
+package ai
+
+import (
+	"math"
+	"math/rand/v2"
+	"pandagame/pkg/game"
+)
+
+// searches the rest of the player's turn, up to Depth of their own decisions, and takes the response with the best outcome
+type Lookahead struct {
+	Depth int
+	r     *rand.Rand
+}
+
+func (s *Lookahead) Choose(g *game.GameState) game.PromptResponse {
+	pid := g.CurrentTurn.PlayerID
+	return best(g.LegalResponses(), s.r, func(response game.PromptResponse) float64 {
+		return s.search(simulate(g, response, s.r), pid, s.Depth-1)
+	})
+}
+
+// the best score the player can reach with depth more decisions. the search stops when their turn or the game ends
+func (s *Lookahead) search(g *game.GameState, pid string, depth int) float64 {
+	if depth <= 0 || g.CurrentTurn.PlayerID != pid || g.CurrentTurn.CurrentPrompt.Action == game.EndGame {
+		return evaluate(g, pid)
+	}
+	legal := g.LegalResponses()
+	if len(legal) == 0 {
+		return evaluate(g, pid)
+	}
+	top := math.Inf(-1)
+	for _, response := range legal {
+		top = max(top, s.search(simulate(g, response, s.r), pid, depth-1))
+	}
+	return top
+}
@@ -0,0 +1,31 @@
+package ai
+
+import (
+	"pandagame/pkg/game"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookaheadEatsWhatItNeeds(t *testing.T) {
+	g := hungryGame()
+	s, _ := NewSeeded(LookaheadStrategy, 1)
+	assert.Equal(t, "p2", s.Choose(g).Selection)
+}
+
+func TestLookaheadPlaysAWholeGame(t *testing.T) {
+	g := game.StartGame([]game.Player{{ID: "a"}, {ID: "b"}}, game.WithSeed(2))
+	bots := map[string]Strategy{}
+	bots["a"], _ = NewSeeded(LookaheadStrategy, 1)
+	bots["b"], _ = NewSeeded(RandomStrategy, 2)
+	prompt := game.GameFlow(g, game.PromptResponse{Action: game.NextPlayerTurn})
+	for prompt.Action != game.EndGame {
+		if !assert.Less(t, len(g.Journal.Entries), 2000) {
+			return
+		}
+		response := bots[g.CurrentTurn.PlayerID].Choose(g)
+		assert.Nil(t, g.ValidatePlayerAction(response))
+		prompt = game.GameFlow(g, response)
+	}
+	assert.NotEmpty(t, g.Results().Winners)
+}
@@ -0,0 +1,114 @@
+// Package ai plays the game for computer players
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"pandagame/pkg/game"
+	"slices"
+)
+
+// picks how the current player answers the current prompt. Strategies don't change the game they are given
+type Strategy interface {
+	Choose(g *game.GameState) game.PromptResponse
+}
+
+const (
+	RandomStrategy    = "random"
+	GreedyStrategy    = "greedy"
+	LookaheadStrategy = "lookahead"
+)
+
+// the strategies a lobby can seat, by name
+var strategies = map[string]func(*rand.Rand) Strategy{
+	RandomStrategy:    func(r *rand.Rand) Strategy { return &Random{r: r} },
+	GreedyStrategy:    func(r *rand.Rand) Strategy { return &Greedy{r: r} },
+	LookaheadStrategy: func(r *rand.Rand) Strategy { return &Lookahead{Depth: 2, r: r} },
+}
+
+// the named strategy, with its own random source
+func New(name string) (Strategy, error) {
+	return NewSeeded(name, rand.Uint64())
+}
+
+// the named strategy, making the same choices every time for the same seed and game
+func NewSeeded(name string, seed uint64) (Strategy, error) {
+	build, ok := strategies[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown strategy: %s", name)
+	}
+	return build(rand.New(rand.NewPCG(seed, seed))), nil
+}
+
+// answers with any legal response
+type Random struct {
+	r *rand.Rand
+}
+
+func (s *Random) Choose(g *game.GameState) game.PromptResponse {
+	legal := g.LegalResponses()
+	if len(legal) == 0 {
+		return game.PromptResponse{}
+	}
+	return legal[s.r.IntN(len(legal))]
+}
+
+// plays the response on a copy of the game. the copy is reseeded so a strategy can't see the real order of the decks or the die
+func simulate(g *game.GameState, response game.PromptResponse, r *rand.Rand) *game.GameState {
+	// the journal and the log don't change how the game plays, and they are the biggest part of the copy
+	cp := *g
+	cp.Journal = game.Journal{}
+	cp.GameLog = nil
+	// the decks only hold values, so they are quicker to copy directly than through json
+	cp.PlotDeck = nil
+	cp.ObjectiveDecks = nil
+	b, err := json.Marshal(&cp)
+	if err != nil {
+		panic(err)
+	}
+	sim := new(game.GameState)
+	if err := json.Unmarshal(b, sim); err != nil {
+		panic(err)
+	}
+	// the player hasn't seen the cards left in the decks, so the copy gets them in an order of its own
+	sim.PlotDeck = shuffled(g.PlotDeck, r)
+	sim.ObjectiveDecks = make(map[game.ObjectiveType][]game.Objective, len(g.ObjectiveDecks))
+	for ot, deck := range g.ObjectiveDecks {
+		sim.ObjectiveDecks[ot] = shuffled(deck, r)
+	}
+	sim.Seed = r.Uint64()
+	game.GameFlow(sim, response)
+	return sim
+}
+
+func shuffled[T any](deck []T, r *rand.Rand) []T {
+	deck = slices.Clone(deck)
+	r.Shuffle(len(deck), func(i, j int) {
+		deck[i], deck[j] = deck[j], deck[i]
+	})
+	return deck
+}
+
+// the highest scoring option. ties are broken at random so a strategy doesn't repeat itself forever
+func best(legal []game.PromptResponse, r *rand.Rand, score func(game.PromptResponse) float64) game.PromptResponse {
+	if len(legal) == 0 {
+		return game.PromptResponse{}
+	}
+	choice := legal[0]
+	top := score(choice)
+	ties := 1
+	for _, response := range legal[1:] {
+		s := score(response)
+		switch {
+		case s > top:
+			choice, top, ties = response, s, 1
+		case s == top:
+			ties++
+			if r.IntN(ties) == 0 {
+				choice = response
+			}
+		}
+	}
+	return choice
+}
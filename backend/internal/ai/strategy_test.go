@@ -0,0 +1,62 @@
+package ai
+
+import (
+	"encoding/json"
+	"pandagame/pkg/game"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewStrategy(t *testing.T) {
+	for _, name := range []string{RandomStrategy, GreedyStrategy, LookaheadStrategy} {
+		s, err := New(name)
+		assert.Nil(t, err)
+		assert.NotNil(t, s)
+	}
+	_, err := New("cheater")
+	assert.NotNil(t, err)
+}
+
+func TestRandomChoosesLegalResponses(t *testing.T) {
+	s, _ := NewSeeded(RandomStrategy, 1)
+	g := game.StartGame([]game.Player{{ID: "a"}, {ID: "b"}}, game.WithSeed(1))
+	game.GameFlow(g, game.PromptResponse{Action: game.NextPlayerTurn})
+	for range 50 {
+		response := s.Choose(g)
+		assert.Nil(t, g.ValidatePlayerAction(response))
+		game.GameFlow(g, response)
+	}
+}
+
+func TestSimulateLeavesTheGameAlone(t *testing.T) {
+	s, _ := NewSeeded(RandomStrategy, 1)
+	g := game.StartGame([]game.Player{{ID: "a"}, {ID: "b"}}, game.WithSeed(1))
+	game.GameFlow(g, game.PromptResponse{Action: game.NextPlayerTurn})
+	entries := len(g.Journal.Entries)
+	prompt := g.CurrentTurn.CurrentPrompt
+	sim := simulate(g, g.LegalResponses()[0], s.(*Random).r)
+	assert.Equal(t, entries, len(g.Journal.Entries))
+	assert.Equal(t, prompt, g.CurrentTurn.CurrentPrompt)
+	assert.NotEqual(t, prompt.Pid, sim.CurrentTurn.CurrentPrompt.Pid)
+}
+
+func TestSimulateCantSeeTheDecks(t *testing.T) {
+	g := game.StartGame([]game.Player{{ID: "a"}, {ID: "b"}}, game.WithSeed(1))
+	game.GameFlow(g, game.PromptResponse{Action: game.NextPlayerTurn})
+	game.GameFlow(g, game.PromptResponse{Action: game.ChooseAction, Selection: game.DrawObjective, Pid: g.CurrentTurn.CurrentPrompt.Pid})
+	assert.Equal(t, game.ChooseObjectiveType, g.CurrentTurn.CurrentPrompt.Action)
+	draw := g.LegalResponses()[0]
+	pid := g.CurrentTurn.PlayerID
+
+	// simulations that draw the same objective, with different randomness, draw different cards
+	drawn := make(map[string]bool)
+	for seed := range uint64(10) {
+		s, _ := NewSeeded(RandomStrategy, seed)
+		sim := simulate(g, draw, s.(*Random).r)
+		hand := sim.GetPlayer(pid).Objectives
+		b, _ := json.Marshal(hand[len(hand)-1])
+		drawn[string(b)] = true
+	}
+	assert.Greater(t, len(drawn), 1)
+}
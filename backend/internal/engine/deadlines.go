@@ -0,0 +1,103 @@
+package engine
+
+import (
+	"sync"
+	"time"
+)
+
+// tracks the outstanding prompt of every game on this server, and fires a callback when a prompt's deadline passes
+type promptWatcher struct {
+	timers map[string]*time.Timer // game id -> timer for the prompt the game is waiting on
+	lock   sync.Mutex
+}
+
+func newPromptWatcher() *promptWatcher {
+	return &promptWatcher{
+		timers: make(map[string]*time.Timer),
+	}
+}
+
+// replaces the game's outstanding prompt. expire is called once the deadline passes, unless the game is watched or stopped again first
+func (w *promptWatcher) Watch(gameId string, deadline time.Time, expire func()) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if t, ok := w.timers[gameId]; ok {
+		t.Stop()
+		delete(w.timers, gameId)
+	}
+	if deadline.IsZero() {
+		return
+	}
+	var t *time.Timer
+	t = time.AfterFunc(time.Until(deadline), func() {
+		w.lock.Lock()
+		current := w.timers[gameId] == t
+		if current {
+			delete(w.timers, gameId)
+		}
+		w.lock.Unlock()
+		if current {
+			expire()
+		}
+	})
+	w.timers[gameId] = t
+}
+
+// stop watching the game, such as when it is over
+func (w *promptWatcher) Stop(gameId string) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if t, ok := w.timers[gameId]; ok {
+		t.Stop()
+		delete(w.timers, gameId)
+	}
+}
+
+// true if the game has a prompt that has not expired yet
+func (w *promptWatcher) Outstanding(gameId string) bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	_, ok := w.timers[gameId]
+	return ok
+}
+
+// serializes the work on each game. connections and prompt deadlines each run on their own goroutine,
+// and every one of them loads, changes and stores the whole game.
+// the locks only reach this process. across instances StoreGame refuses an update made from a stale load
+type gameLocks struct {
+	games map[string]*gameLock
+	lock  sync.Mutex
+}
+
+type gameLock struct {
+	sync.Mutex
+	holders int // goroutines holding or waiting on the lock. it is dropped when there are none
+}
+
+func newGameLocks() *gameLocks {
+	return &gameLocks{
+		games: make(map[string]*gameLock),
+	}
+}
+
+// waits for the game to be free and returns the func that frees it again
+func (l *gameLocks) Lock(gameId string) func() {
+	l.lock.Lock()
+	gl, ok := l.games[gameId]
+	if !ok {
+		gl = &gameLock{}
+		l.games[gameId] = gl
+	}
+	gl.holders++
+	l.lock.Unlock()
+	gl.Lock()
+	return func() {
+		gl.Unlock()
+		l.lock.Lock()
+		defer l.lock.Unlock()
+		gl.holders--
+		if gl.holders == 0 {
+			delete(l.games, gameId)
+		}
+	}
+}
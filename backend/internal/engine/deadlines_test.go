@@ -0,0 +1,78 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPromptWatcherExpires(t *testing.T) {
+	w := newPromptWatcher()
+	expired := make(chan string, 1)
+	w.Watch("game", time.Now().Add(10*time.Millisecond), func() {
+		expired <- "game"
+	})
+	assert.True(t, w.Outstanding("game"))
+	select {
+	case id := <-expired:
+		assert.Equal(t, "game", id)
+	case <-time.After(time.Second):
+		t.Fatal("prompt never expired")
+	}
+	assert.False(t, w.Outstanding("game"))
+}
+
+func TestPromptWatcherReplacesAndStops(t *testing.T) {
+	w := newPromptWatcher()
+	expired := make(chan string, 2)
+	w.Watch("game", time.Now().Add(10*time.Millisecond), func() {
+		expired <- "first"
+	})
+	// answering the first prompt issues a new one, which replaces the old deadline
+	w.Watch("game", time.Now().Add(20*time.Millisecond), func() {
+		expired <- "second"
+	})
+	select {
+	case id := <-expired:
+		assert.Equal(t, "second", id)
+	case <-time.After(time.Second):
+		t.Fatal("prompt never expired")
+	}
+
+	w.Watch("game", time.Now().Add(10*time.Millisecond), func() {
+		expired <- "third"
+	})
+	w.Stop("game")
+	select {
+	case id := <-expired:
+		t.Fatalf("stopped prompt %s expired", id)
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.False(t, w.Outstanding("game"))
+}
+
+func TestGameLocks(t *testing.T) {
+	l := newGameLocks()
+	unlock := l.Lock("game")
+	// other games aren't held up
+	l.Lock("other")()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		l.Lock("game")()
+	}()
+	select {
+	case <-done:
+		t.Fatal("the game was locked twice")
+	case <-time.After(20 * time.Millisecond):
+	}
+	unlock()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the game was never unlocked")
+	}
+	assert.Empty(t, l.games, "locks nobody holds are dropped")
+}
@@ -1,37 +1,41 @@
 package engine
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math/rand/v2"
 	"net/http"
+	"pandagame/internal/ai"
 	"pandagame/internal/config"
 	"pandagame/internal/framework"
-	"pandagame/internal/game"
 	"pandagame/internal/web"
+	"pandagame/pkg/game"
+	"pandagame/pkg/protocol"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/surrealdb/surrealdb.go"
 	"github.com/surrealdb/surrealdb.go/pkg/models"
 )
 
-type ClientEventShell struct {
-	MessageType string          `json:"messageType"`
-	Message     json.RawMessage `json:"message"`
-}
-
-type ServerEventShell struct {
-	MessageType string `json:"messageType"`
-	Message     any    `json:"message"`
-}
-
 type GameRecord struct {
-	RID   *models.RecordID `json:"id"`
-	GID   string           `json:"gameId"`
-	State *game.GameState  `json:"state"`
-	Lobby game.Lobby       `json:"lobby"`
+	RID     *models.RecordID  `json:"id"`
+	GID     string            `json:"gameId"`
+	State   *game.GameState   `json:"state"`
+	Lobby   game.Lobby        `json:"lobby"`
+	Results *game.GameResults `json:"results"`
+	Seed    uint64            `json:"seed"`
+	// messages sent to the room, from the lobby on
+	ChatLog []game.ChatMessage `json:"chatLog"`
+	// counts the stores. an update only lands on the version it was loaded at
+	Version int `json:"version"`
 }
 
+// another server instance stored the game after it was loaded here. the change is dropped
+var ErrStaleGame = errors.New("the game was changed by another server")
+
 func ConnectionAuthValidator(w http.ResponseWriter, r *http.Request) error {
 	token, err := web.GetToken(r)
 	if err != nil {
@@ -54,23 +58,41 @@ func ConnectionAuthValidator(w http.ResponseWriter, r *http.Request) error {
 	return nil
 }
 
-type PandaGameEngine struct{}
+type PandaGameEngine struct {
+	prompts  *promptWatcher
+	games    *gameLocks
+	dispatch func([]framework.Event)
+}
+
+func NewPandaGameEngine() *PandaGameEngine {
+	return &PandaGameEngine{
+		prompts:  newPromptWatcher(),
+		games:    newGameLocks(),
+		dispatch: func([]framework.Event) {},
+	}
+}
+
+// set how events the engine produces on its own (like auto play when a prompt expires) are delivered
+func (p *PandaGameEngine) SetDispatcher(d func([]framework.Event)) {
+	p.dispatch = d
+}
 
 func (p *PandaGameEngine) HandleEvent(event framework.Event) ([]framework.Event, error) {
-	switch ClientEventType(event.Type) {
-	case CreateGame:
+	switch protocol.ClientEventType(event.Type) {
+	case protocol.CreateGame:
 		gameId := uuid.NewString()
 		l := game.Lobby{
 			Host:       event.SourceId,
 			Players:    []string{event.SourceId},
 			Spectators: make([]string, 0),
 			GameId:     gameId,
+			Settings:   game.DefaultSettings(),
 		}
 		response := framework.Event{
 			Source:  framework.TargetServer,
 			Dest:    framework.TargetClient,
 			DestId:  event.SourceId,
-			Type:    string(LobbyUpdate),
+			Type:    string(protocol.LobbyUpdate),
 			Payload: l,
 		}
 		join := framework.Event{
@@ -89,117 +111,542 @@ func (p *PandaGameEngine) HandleEvent(event framework.Event) ([]framework.Event,
 			return make([]framework.Event, 0), err
 		}
 		return []framework.Event{join, response}, nil
-	case Matchmake:
+	case protocol.Matchmake:
 		//
-	case CancelMatchmake:
+	case protocol.CancelMatchmake:
 		//
-	case JoinGame:
+	case protocol.JoinGame:
 		gameId := event.Payload.(string)
-		gr, err := GetGame(gameId)
+		defer p.games.Lock(gameId)()
+		gr, err := p.loadGame(gameId)
 		if err != nil {
 			return make([]framework.Event, 0), err
 		}
-		if len(gr.Lobby.Players) < 4 {
-			gr.Lobby.Players = append(gr.Lobby.Players, event.SourceId)
-		} else {
-			gr.Lobby.Spectators = append(gr.Lobby.Spectators, event.SourceId)
-		}
-		response := framework.Event{
-			Source:   framework.TargetServer,
-			SourceId: event.SourceId,
-			Dest:     framework.TargetJoinGroup,
-			DestId:   gameId,
-		}
-		broadcast := framework.Event{
-			Source:  framework.TargetServer,
-			Dest:    framework.TargetGroup,
-			DestId:  gameId,
-			Payload: gr.Lobby,
-			Type:    string(LobbyUpdate),
+		events, joined := p.join(gr, event.SourceId)
+		if joined {
+			if err := StoreGame(gr, true); err != nil {
+				return make([]framework.Event, 0), err
+			}
 		}
-		if err := StoreGame(gr, true); err != nil {
-			return make([]framework.Event, 0), err
+		return events, nil
+	case protocol.LeaveGame:
+		gameId := event.Payload.(string)
+		defer p.games.Lock(gameId)()
+		gr, err := p.loadGame(gameId)
+		if err != nil {
+			return []framework.Event{}, err
 		}
-		return []framework.Event{response, broadcast}, nil
-	case LeaveGame:
-		// TODO
-	case StartGame:
+		return p.leave(gr, event.SourceId)
+	case protocol.StartGame:
 		gameId := event.Payload.(string)
-		gr, err := GetGame(gameId)
+		defer p.games.Lock(gameId)()
+		gr, err := p.loadGame(gameId)
 		if err != nil {
 			return []framework.Event{}, err
 		}
 		players := make([]game.Player, len(gr.Lobby.Players))
 		for i, p := range gr.Lobby.Players {
+			name := "TODO" // get user name from DB base on ID?
+			if strategy, ok := gr.Lobby.Bots[p]; ok {
+				name = strategy + " bot"
+			}
 			players[i] = game.Player{
 				ID:    p,
-				Name:  "TODO", // get user name from DB base on ID?
+				Name:  name,
 				Order: i + 1,
 			}
 		}
-		// TODO apply settings from lobby
-		g := game.StartGame(players)
+		gr.Lobby.Started = true
+		gr.Seed = rand.Uint64()
+		g := game.StartGame(players, game.WithSeed(gr.Seed), game.WithSettings(gr.Lobby.Settings))
 		gr.State = g
 		broadcast := framework.Event{
 			Source:  framework.TargetServer,
 			Dest:    framework.TargetGroup,
 			DestId:  gameId,
 			Payload: *g,
-			Type:    string(GameStart),
+			Type:    string(protocol.GameStart),
+		}
+		// a bot may have the first turn
+		events, err := p.advance(gr, game.GameFlow(g, game.PromptResponse{Action: game.NextPlayerTurn}))
+		return append([]framework.Event{broadcast}, events...), err
+
+	case protocol.Reprompt:
+		gameId := event.Payload.(string)
+		defer p.games.Lock(gameId)()
+		gr, err := p.loadGame(gameId)
+		if err != nil {
+			return []framework.Event{}, err
+		}
+		return p.rejoin(gr, event.SourceId), nil
+	case protocol.GameChat:
+		msg := structConverter[game.ChatMessage](event.Payload)
+		defer p.games.Lock(msg.Gid)()
+		gr, err := p.loadGame(msg.Gid)
+		if err != nil {
+			return []framework.Event{}, err
+		}
+		events, ok := postChat(gr, event.SourceId, msg.Message, time.Now())
+		if ok {
+			if err := StoreGame(gr, true); err != nil {
+				return make([]framework.Event, 0), err
+			}
+		}
+		return events, nil
+	case protocol.FetchChat:
+		request := structConverter[game.ChatHistoryRequest](event.Payload)
+		defer p.games.Lock(request.Gid)()
+		gr, err := p.loadGame(request.Gid)
+		if err != nil {
+			return []framework.Event{}, err
+		}
+		return []framework.Event{chatHistory(gr, event.SourceId, request)}, nil
+	case protocol.TakeAction:
+		action := structConverter[game.PromptResponse](event.Payload)
+		defer p.games.Lock(action.Gid)()
+		gr, err := p.loadGame(action.Gid)
+		if err != nil {
+			return []framework.Event{}, err
+		}
+		if err := checkTurn(gr, event.SourceId); err != nil {
+			return []framework.Event{warn(event.SourceId, *err)}, nil
+		}
+		if action.Action == game.Forfeit {
+			return []framework.Event{}, errors.New("forfeit by leaving the game")
+		}
+		// the game would quietly prompt again. tell the player what was wrong instead
+		if err := gr.State.ValidatePlayerAction(action); err != nil {
+			return []framework.Event{warn(event.SourceId, *err)}, nil
+		}
+		return p.advance(gr, game.GameFlow(gr.State, action))
+	case protocol.ChangeSettings:
+		change := structConverter[game.SettingsChange](event.Payload)
+		defer p.games.Lock(change.Gid)()
+		gr, err := p.loadGame(change.Gid)
+		if err != nil {
+			return []framework.Event{}, err
 		}
-		firstPrompt := game.GameFlow(g, game.PromptResponse{Action: game.NextPlayerTurn})
-		prompt := framework.Event{
+		if gr.Lobby.Host != event.SourceId {
+			return []framework.Event{}, errors.New("only the host can change settings")
+		}
+		if gr.State != nil {
+			return []framework.Event{}, errors.New("settings can't be changed once the game has started")
+		}
+		if err := change.Settings.Validate(); err != nil {
+			return []framework.Event{}, err
+		}
+		gr.Lobby.Settings = change.Settings
+		broadcast := framework.Event{
 			Source:  framework.TargetServer,
-			Dest:    framework.TargetClient,
-			DestId:  firstPrompt.Pid,
-			Type:    string(ActionPrompt),
-			Payload: firstPrompt,
+			Dest:    framework.TargetGroup,
+			DestId:  gr.GID,
+			Payload: gr.Lobby,
+			Type:    string(protocol.LobbyUpdate),
 		}
 		if err := StoreGame(gr, true); err != nil {
 			return make([]framework.Event, 0), err
 		}
-		return []framework.Event{broadcast, prompt}, nil
-
-	case Reprompt:
-		//
-	case GameChat:
-		// TODO get game state
-		g := game.GameState{} // placeholder
-		msg := event.Payload.(game.ChatMessage)
-		g.ChatLog = append(g.ChatLog, msg)
-		// TODO store game state
-		// TODO broadcast game state update
-	case TakeAction:
-		action := event.Payload.(game.PromptResponse)
-		gr, err := GetGame(action.Gid)
+		return []framework.Event{broadcast}, nil
+	case protocol.AddBot:
+		request := structConverter[game.BotRequest](event.Payload)
+		defer p.games.Lock(request.Gid)()
+		gr, err := p.loadGame(request.Gid)
 		if err != nil {
 			return []framework.Event{}, err
 		}
-
-		nextPrompt := game.GameFlow(gr.State, action)
-		response := framework.Event{
-			Source:  framework.TargetServer,
-			Dest:    framework.TargetClient,
-			DestId:  nextPrompt.Pid,
-			Type:    string(ActionPrompt),
-			Payload: nextPrompt,
+		if gr.Lobby.Host != event.SourceId {
+			return []framework.Event{}, errors.New("only the host can add bots")
+		}
+		if gr.State != nil {
+			return []framework.Event{}, errors.New("bots can't be added once the game has started")
+		}
+		if _, err := ai.New(request.Strategy); err != nil {
+			return []framework.Event{}, err
+		}
+		if _, err := gr.Lobby.AddBot(request.Strategy); err != nil {
+			return []framework.Event{}, err
 		}
 		broadcast := framework.Event{
 			Source:  framework.TargetServer,
 			Dest:    framework.TargetGroup,
 			DestId:  gr.GID,
-			Payload: *gr.State,
-			Type:    string(GameStart),
+			Payload: gr.Lobby,
+			Type:    string(protocol.LobbyUpdate),
+		}
+		if err := StoreGame(gr, true); err != nil {
+			return make([]framework.Event, 0), err
 		}
-		return []framework.Event{broadcast, response}, nil
-	case ChangeSettings:
-		// TODO will be json, part of lobby
+		return []framework.Event{broadcast}, nil
 	default:
 		return make([]framework.Event, 0), fmt.Errorf("invalid message type: %s", event.Type)
 	}
 	return []framework.Event{}, nil
 }
 
+// seats the person, or has them spectate when the seats are full, returning the events for it and whether the lobby changed.
+// someone already in the game, like after a page refresh, only needs to catch up
+func (p *PandaGameEngine) join(gr *GameRecord, pid string) ([]framework.Event, bool) {
+	if gr.Lobby.Has(pid) {
+		return p.rejoin(gr, pid), false
+	}
+	if len(gr.Lobby.Players) < game.MaxPlayers {
+		gr.Lobby.Players = append(gr.Lobby.Players, pid)
+	} else {
+		gr.Lobby.Spectators = append(gr.Lobby.Spectators, pid)
+	}
+	response := framework.Event{
+		Source:   framework.TargetServer,
+		SourceId: pid,
+		Dest:     framework.TargetJoinGroup,
+		DestId:   gr.GID,
+	}
+	broadcast := framework.Event{
+		Source:  framework.TargetServer,
+		Dest:    framework.TargetGroup,
+		DestId:  gr.GID,
+		Payload: gr.Lobby,
+		Type:    string(protocol.LobbyUpdate),
+	}
+	return []framework.Event{response, broadcast}, true
+}
+
+// takes the player out of the game's group and lobby. a lobby nobody is left in is deleted.
+// leaving a game in progress forfeits, or hands the seat to a bot if the game's settings say so
+func (p *PandaGameEngine) leave(gr *GameRecord, pid string) ([]framework.Event, error) {
+	leave := framework.Event{
+		Source:   framework.TargetServer,
+		SourceId: pid,
+		Dest:     framework.TargetLeaveGroup,
+		DestId:   gr.GID,
+	}
+	seated := checkTurn(gr, pid)
+	playing := seated == nil || seated.Code == game.NotYourTurnCode
+	if playing && gr.Lobby.Settings.OnLeave == game.BotOnLeave {
+		gr.Lobby.HandToBot(pid, ai.GreedyStrategy)
+	}
+	gr.Lobby.Leave(pid)
+	if gr.State == nil && gr.Lobby.Empty() {
+		return []framework.Event{leave}, DeleteGame(gr)
+	}
+	broadcast := framework.Event{
+		Source:  framework.TargetServer,
+		Dest:    framework.TargetGroup,
+		DestId:  gr.GID,
+		Payload: gr.Lobby,
+		Type:    string(protocol.LobbyUpdate),
+	}
+	if !playing {
+		if err := StoreGame(gr, true); err != nil {
+			return make([]framework.Event, 0), err
+		}
+		return []framework.Event{leave, broadcast}, nil
+	}
+	prompt := gr.State.CurrentTurn.CurrentPrompt.Remaining(time.Now())
+	if !gr.Lobby.IsBot(pid) {
+		prompt = game.GameFlow(gr.State, game.PromptResponse{Action: game.Forfeit, Selection: pid})
+	}
+	// the bot, or the next player, may need to be prompted
+	events, err := p.advance(gr, prompt)
+	return append([]framework.Event{leave, broadcast}, events...), err
+}
+
+// brings a player who lost their connection back into the game: they rejoin the group and are sent the game as it is now,
+// and the prompt they were answering with the time that is left on it
+func (p *PandaGameEngine) rejoin(gr *GameRecord, pid string) []framework.Event {
+	if !gr.Lobby.Has(pid) {
+		return []framework.Event{warn(pid, game.ValidationError{Code: game.NotPlayingCode, Message: "you are not in this game"})}
+	}
+	events := []framework.Event{
+		{
+			Source:   framework.TargetServer,
+			SourceId: pid,
+			Dest:     framework.TargetJoinGroup,
+			DestId:   gr.GID,
+		},
+		{
+			Source:  framework.TargetServer,
+			Dest:    framework.TargetClient,
+			DestId:  pid,
+			Payload: gr.Lobby,
+			Type:    string(protocol.LobbyUpdate),
+		},
+	}
+	if gr.State == nil {
+		return events
+	}
+	events = append(events, framework.Event{
+		Source:  framework.TargetServer,
+		Dest:    framework.TargetClient,
+		DestId:  pid,
+		Payload: *gr.State,
+		Type:    string(protocol.GameUpdate),
+	})
+	if gr.Results != nil {
+		return append(events, framework.Event{
+			Source:  framework.TargetServer,
+			Dest:    framework.TargetClient,
+			DestId:  pid,
+			Payload: *gr.Results,
+			Type:    string(protocol.GameOver),
+		})
+	}
+	if gr.State.CurrentTurn.PlayerID == pid && gr.State.CurrentTurn.CurrentPrompt.Action != game.EndGame {
+		// watching the deadline again also covers a server that restarted while the prompt was out
+		events = append(events, p.issuePrompt(gr, gr.State.CurrentTurn.CurrentPrompt.Remaining(time.Now())))
+	}
+	return events
+}
+
+// stamps the person's message and adds it to the game's chat, returning the events that deliver it and whether it was added.
+// when spectators chat separately, their messages only go to the other spectators
+func postChat(gr *GameRecord, pid string, text string, t time.Time) ([]framework.Event, bool) {
+	if !gr.Lobby.Has(pid) {
+		return []framework.Event{warn(pid, game.ValidationError{Code: game.NotPlayingCode, Message: "you are not in this game"})}, false
+	}
+	msg := game.ChatMessage{
+		From:      pid,
+		Message:   strings.TrimSpace(text),
+		Gid:       gr.GID,
+		Timestamp: t,
+		Spectator: gr.Lobby.IsSpectator(pid),
+	}
+	if err := game.ValidateChat(gr.ChatLog, msg); err != nil {
+		return []framework.Event{warn(pid, *err)}, false
+	}
+	gr.ChatLog = append(gr.ChatLog, msg)
+	if !msg.Spectator || !gr.Lobby.Settings.SeparateSpectatorChat {
+		return []framework.Event{{
+			Source:  framework.TargetServer,
+			Dest:    framework.TargetGroup,
+			DestId:  gr.GID,
+			Payload: msg,
+			Type:    string(protocol.ChatMessage),
+		}}, true
+	}
+	events := make([]framework.Event, len(gr.Lobby.Spectators))
+	for i, s := range gr.Lobby.Spectators {
+		events[i] = framework.Event{
+			Source:  framework.TargetServer,
+			Dest:    framework.TargetClient,
+			DestId:  s,
+			Payload: msg,
+			Type:    string(protocol.ChatMessage),
+		}
+	}
+	return events, true
+}
+
+// the page of chat the person asked for, leaving out what they aren't allowed to read
+func chatHistory(gr *GameRecord, pid string, request game.ChatHistoryRequest) framework.Event {
+	if !gr.Lobby.Has(pid) {
+		return warn(pid, game.ValidationError{Code: game.NotPlayingCode, Message: "you are not in this game"})
+	}
+	page := game.PageChat(gr.Lobby.VisibleChat(gr.ChatLog, pid), request.Before, request.Limit)
+	page.Gid = gr.GID
+	return framework.Event{
+		Source:  framework.TargetServer,
+		Dest:    framework.TargetClient,
+		DestId:  pid,
+		Payload: page,
+		Type:    string(protocol.ChatHistory),
+	}
+}
+
+// returns an error unless the player is seated in the game and it is their turn. prompt ids are broadcast, so knowing one proves nothing
+func checkTurn(gr *GameRecord, pid string) *game.ValidationError {
+	if gr.State == nil {
+		return &game.ValidationError{Code: game.NotStartedCode, Message: "the game has not started"}
+	}
+	if gr.State.CurrentTurn.CurrentPrompt.Action == game.EndGame {
+		return &game.ValidationError{Code: game.GameOverCode, Message: "the game is over"}
+	}
+	p := gr.State.GetPlayer(pid)
+	if p == nil || gr.Lobby.IsBot(pid) {
+		return &game.ValidationError{Code: game.NotPlayingCode, Message: "you are not playing in this game"}
+	}
+	if p.Forfeited {
+		return &game.ValidationError{Code: game.NotPlayingCode, Message: "you have forfeited this game"}
+	}
+	if gr.State.CurrentTurn.PlayerID != pid {
+		return &game.ValidationError{Code: game.NotYourTurnCode, Message: "it is not your turn"}
+	}
+	return nil
+}
+
+// builds the event that tells a client why its last message was rejected
+func warn(pid string, err game.ValidationError) framework.Event {
+	return framework.Event{
+		Source:  framework.TargetServer,
+		Dest:    framework.TargetClient,
+		DestId:  pid,
+		Type:    string(protocol.Warning),
+		Payload: err,
+	}
+}
+
+// stores the game after it has moved on to nextPrompt, and builds the events that tell everyone about it.
+// a bot's prompt isn't answered here. its answer is scheduled, so this event doesn't hold the game for the rest of the bots' turns
+func (p *PandaGameEngine) advance(gr *GameRecord, nextPrompt game.Prompt) ([]framework.Event, error) {
+	broadcast := framework.Event{
+		Source:  framework.TargetServer,
+		Dest:    framework.TargetGroup,
+		DestId:  gr.GID,
+		Payload: *gr.State,
+		Type:    string(protocol.GameUpdate),
+	}
+	if nextPrompt.Action == game.EndGame {
+		p.prompts.Stop(gr.GID)
+		results := gr.State.Results()
+		gr.Results = &results
+		gameOver := framework.Event{
+			Source:  framework.TargetServer,
+			Dest:    framework.TargetGroup,
+			DestId:  gr.GID,
+			Payload: results,
+			Type:    string(protocol.GameOver),
+		}
+		if err := StoreGame(gr, true); err != nil {
+			return make([]framework.Event, 0), err
+		}
+		return []framework.Event{broadcast, gameOver}, nil
+	}
+	if gr.Lobby.IsBot(gr.State.CurrentTurn.PlayerID) {
+		if err := StoreGame(gr, true); err != nil {
+			return make([]framework.Event, 0), err
+		}
+		p.scheduleBot(gr.GID, nextPrompt)
+		return []framework.Event{broadcast}, nil
+	}
+	response := p.issuePrompt(gr, nextPrompt)
+	if err := StoreGame(gr, true); err != nil {
+		return make([]framework.Event, 0), err
+	}
+	return []framework.Event{broadcast, response}, nil
+}
+
+// plays one answer for the bot whose turn it is, returning the next prompt and whether anything was accepted.
+// the bot is seeded from the game, so replaying the game's record plays the same bots the same way
+func playBot(gr *GameRecord) (game.Prompt, bool) {
+	entries := len(gr.State.Journal.Entries)
+	strategy, err := ai.NewSeeded(gr.Lobby.Bots[gr.State.CurrentTurn.PlayerID], gr.Seed+uint64(entries))
+	if err != nil {
+		strategy, _ = ai.NewSeeded(ai.RandomStrategy, gr.Seed+uint64(entries))
+	}
+	prompt := game.GameFlow(gr.State, strategy.Choose(gr.State))
+	if len(gr.State.Journal.Entries) == entries {
+		// the bot's answer wasn't accepted. play for it so the game can't stall
+		prompt = game.GameFlow(gr.State, game.AutoPlay(gr.State.CurrentTurn))
+	}
+	if len(gr.State.Journal.Entries) == entries {
+		// nothing the bot can say is accepted. it leaves the game rather than hold everyone up
+		pid := gr.State.CurrentTurn.PlayerID
+		slog.Warn("bot is stuck on a prompt, forfeiting its seat", slog.String("gameId", gr.GID), slog.String("playerId", pid), slog.Any("prompt", prompt))
+		prompt = game.GameFlow(gr.State, game.PromptResponse{Action: game.Forfeit, Selection: pid})
+	}
+	return prompt, len(gr.State.Journal.Entries) > entries
+}
+
+// how long a bot waits before it answers, so people can follow what the bots are doing
+const botDelay = 500 * time.Millisecond
+
+// has the bot answer the prompt once botDelay has passed. like a deadline, it is dropped if the game is watched again first
+func (p *PandaGameEngine) scheduleBot(gameId string, prompt game.Prompt) {
+	p.prompts.Watch(gameId, time.Now().Add(botDelay), func() {
+		p.answerForBot(gameId, prompt.Pid)
+	})
+}
+
+// called when a bot's answer is due. every answer takes the game's lock on its own, so a game that only bots are left in
+// plays out one answer at a time in the background, and people's events and deadlines are handled in between
+func (p *PandaGameEngine) answerForBot(gameId, promptId string) {
+	unlock := p.games.Lock(gameId)
+	events, err := p.botAnswer(gameId, promptId)
+	unlock()
+	if err != nil {
+		slog.Warn("failed to play a bot's answer", slog.String("gameId", gameId), slog.String("error", err.Error()))
+		return
+	}
+	p.dispatch(events)
+}
+
+// plays the bot's answer, if the game is still waiting on the bot now that it holds the game's lock
+func (p *PandaGameEngine) botAnswer(gameId, promptId string) ([]framework.Event, error) {
+	gr, err := GetGame(gameId)
+	if err != nil {
+		return nil, err
+	}
+	if gr.State == nil || gr.Results != nil || gr.State.CurrentTurn.CurrentPrompt.Pid != promptId || !gr.Lobby.IsBot(gr.State.CurrentTurn.PlayerID) {
+		// the game moved on without the bot
+		return nil, nil
+	}
+	prompt, ok := playBot(gr)
+	if !ok {
+		return nil, fmt.Errorf("no answer for %s was accepted", gr.State.CurrentTurn.PlayerID)
+	}
+	return p.advance(gr, prompt)
+}
+
+// addresses the copy of the prompt that is sent out to its game, starts watching its deadline, and builds the event that sends it to the current player
+func (p *PandaGameEngine) issuePrompt(gr *GameRecord, prompt game.Prompt) framework.Event {
+	prompt.Gid = gr.GID
+	p.watch(gr.GID, prompt)
+	return framework.Event{
+		Source:  framework.TargetServer,
+		Dest:    framework.TargetClient,
+		DestId:  gr.State.CurrentTurn.PlayerID,
+		Type:    string(protocol.ActionPrompt),
+		Payload: prompt,
+	}
+}
+
+func (p *PandaGameEngine) watch(gameId string, prompt game.Prompt) {
+	p.prompts.Watch(gameId, prompt.Deadline, func() {
+		p.expirePrompt(gameId, prompt.Pid)
+	})
+}
+
+// loads the game, and watches its prompt's deadline (or schedules the bot's answer) again if this server isn't already, such as after a restart
+func (p *PandaGameEngine) loadGame(gameId string) (*GameRecord, error) {
+	gr, err := GetGame(gameId)
+	if err != nil {
+		return nil, err
+	}
+	if gr.State != nil && gr.Results == nil && !p.prompts.Outstanding(gameId) {
+		if gr.Lobby.IsBot(gr.State.CurrentTurn.PlayerID) {
+			p.scheduleBot(gameId, gr.State.CurrentTurn.CurrentPrompt)
+		} else {
+			p.watch(gameId, gr.State.CurrentTurn.CurrentPrompt)
+		}
+	}
+	return gr, nil
+}
+
+// called when a prompt's deadline passes. If the game is still waiting on that prompt, the game plays for the player (or forfeits them)
+func (p *PandaGameEngine) expirePrompt(gameId, promptId string) {
+	unlock := p.games.Lock(gameId)
+	events, err := p.expire(gameId, promptId)
+	unlock()
+	if err != nil {
+		slog.Warn("failed to auto play expired prompt", slog.String("gameId", gameId), slog.String("error", err.Error()))
+		return
+	}
+	p.dispatch(events)
+}
+
+// auto plays the prompt, if the game is still waiting on it now that it holds the game's lock
+func (p *PandaGameEngine) expire(gameId, promptId string) ([]framework.Event, error) {
+	gr, err := GetGame(gameId)
+	if err != nil {
+		return nil, err
+	}
+	if gr.State == nil || gr.Results != nil || gr.State.CurrentTurn.CurrentPrompt.Pid != promptId {
+		// the prompt was answered in time
+		return nil, nil
+	}
+	slog.Info("prompt expired", slog.String("gameId", gameId), slog.String("playerId", gr.State.CurrentTurn.PlayerID))
+	return p.advance(gr, game.Expire(gr.State))
+}
+
 func recordID(gameId string) *models.RecordID {
 	return &models.RecordID{
 		ID:    gameId,
@@ -217,10 +664,41 @@ func GetGame(gameId string) (*GameRecord, error) {
 	return record, nil
 }
 
+// rebuilds a stored game as it was after the first step journaled responses
+func ReplayGame(gameId string, step int) (*game.GameState, error) {
+	gr, err := GetGame(gameId)
+	if err != nil {
+		return nil, err
+	}
+	if gr.State == nil {
+		return nil, fmt.Errorf("game %s has not started", gameId)
+	}
+	return game.Replay(gr.Seed, gr.State.Journal.UpTo(step))
+}
+
+// stores a new game, or updates a loaded one if nothing else has stored it since.
+// the engine's locks only order the work of one process, so this is what keeps instances behind the relayer from overwriting each other
 func StoreGame(gr *GameRecord, update bool) error {
 	db, _ := config.AdminSurreal()
-	_, err := surrealdb.Upsert[[]GameRecord](db, models.Table("game"), gr)
-	return err
+	if !update {
+		_, err := surrealdb.Upsert[[]GameRecord](db, models.Table("game"), gr)
+		return err
+	}
+	next := *gr
+	next.Version++
+	res, err := surrealdb.Query[[]GameRecord](db, "UPDATE $id CONTENT $record WHERE (version ?? 0) = $version", map[string]any{
+		"id":      *gr.RID,
+		"record":  next,
+		"version": gr.Version,
+	})
+	if err != nil {
+		return err
+	}
+	if res == nil || len(*res) == 0 || len((*res)[0].Result) == 0 {
+		return ErrStaleGame
+	}
+	gr.Version = next.Version
+	return nil
 }
 
 func DeleteGame(gr *GameRecord) error {
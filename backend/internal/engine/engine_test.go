@@ -0,0 +1,237 @@
+package engine
+
+import (
+	"pandagame/internal/ai"
+	"pandagame/internal/framework"
+	"pandagame/pkg/game"
+	"pandagame/pkg/protocol"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlayBots(t *testing.T) {
+	l := game.Lobby{Players: []string{"human"}}
+	l.AddBot(ai.RandomStrategy)
+	l.AddBot(ai.GreedyStrategy)
+	players := make([]game.Player, len(l.Players))
+	for i, pid := range l.Players {
+		players[i] = game.Player{ID: pid, Order: i + 1}
+	}
+	gr := &GameRecord{Lobby: l, State: game.StartGame(players, game.WithSeed(3))}
+
+	// bots answer one prompt at a time until the person has to answer
+	prompt := playOut(t, gr, game.GameFlow(gr.State, game.PromptResponse{Action: game.NextPlayerTurn}))
+	assert.Equal(t, "human", gr.State.CurrentTurn.PlayerID)
+	assert.Equal(t, gr.State.CurrentTurn.CurrentPrompt, prompt)
+
+	// once the person is gone, the bots finish the game
+	prompt = game.GameFlow(gr.State, game.PromptResponse{Action: game.Forfeit, Selection: "human"})
+	prompt = playOut(t, gr, prompt)
+	assert.Equal(t, game.EndGame, prompt.Action)
+}
+
+// has the bots answer, the way their scheduled answers would, until a person has to or the game is over
+func playOut(t *testing.T, gr *GameRecord, prompt game.Prompt) game.Prompt {
+	for range 5000 {
+		if prompt.Action == game.EndGame || !gr.Lobby.IsBot(gr.State.CurrentTurn.PlayerID) {
+			return prompt
+		}
+		var ok bool
+		prompt, ok = playBot(gr)
+		assert.True(t, ok)
+	}
+	t.Fatal("the bots didn't finish")
+	return prompt
+}
+
+func TestPlayBotIsReproducible(t *testing.T) {
+	play := func() []game.PromptResponse {
+		l := game.Lobby{}
+		l.AddBot(ai.RandomStrategy)
+		l.AddBot(ai.RandomStrategy)
+		players := []game.Player{{ID: "bot:1", Order: 1}, {ID: "bot:2", Order: 2}}
+		gr := &GameRecord{Lobby: l, Seed: 4, State: game.StartGame(players, game.WithSeed(4))}
+		prompt := game.GameFlow(gr.State, game.PromptResponse{Action: game.NextPlayerTurn})
+		for range 50 {
+			prompt, _ = playBot(gr)
+		}
+		assert.NotEqual(t, game.EndGame, prompt.Action)
+		responses := make([]game.PromptResponse, len(gr.State.Journal.Entries))
+		for i, e := range gr.State.Journal.Entries {
+			responses[i] = e.Response
+		}
+		return responses
+	}
+	// the bots are seeded from the game, so a game's record plays out the same way again
+	assert.Equal(t, play(), play())
+}
+
+func TestCheckTurn(t *testing.T) {
+	gr := &GameRecord{Lobby: game.Lobby{Players: []string{"p1", "p2", "p3"}}}
+	assert.Equal(t, game.NotStartedCode, checkTurn(gr, "p1").Code)
+
+	players := []game.Player{{ID: "p1", Order: 1}, {ID: "p2", Order: 2}, {ID: "p3", Order: 3}}
+	gr.State = game.StartGame(players, game.WithSeed(5))
+	game.GameFlow(gr.State, game.PromptResponse{Action: game.NextPlayerTurn})
+	current := gr.State.CurrentTurn.PlayerID
+
+	assert.Nil(t, checkTurn(gr, current))
+	assert.Equal(t, game.NotPlayingCode, checkTurn(gr, "spectator").Code)
+	for _, p := range gr.State.Players {
+		if p.ID != current {
+			assert.Equal(t, game.NotYourTurnCode, checkTurn(gr, p.ID).Code)
+		}
+	}
+
+	gr.State.GetPlayer(current).Forfeited = true
+	assert.Equal(t, game.NotPlayingCode, checkTurn(gr, current).Code)
+
+	gr.State.CurrentTurn.CurrentPrompt = game.Prompt{Action: game.EndGame}
+	assert.Equal(t, game.GameOverCode, checkTurn(gr, current).Code)
+}
+
+func TestBotTakesOverSeat(t *testing.T) {
+	l := game.Lobby{Host: "a", Players: []string{"a", "b"}}
+	players := []game.Player{{ID: "a", Order: 1}, {ID: "b", Order: 2}}
+	gr := &GameRecord{Lobby: l, State: game.StartGame(players, game.WithSeed(8))}
+	prompt := game.GameFlow(gr.State, game.PromptResponse{Action: game.NextPlayerTurn})
+
+	// both people leave and their seats play on without them
+	for _, pid := range []string{"a", "b"} {
+		gr.Lobby.HandToBot(pid, ai.RandomStrategy)
+		gr.Lobby.Leave(pid)
+		assert.Equal(t, game.NotPlayingCode, checkTurn(gr, pid).Code)
+	}
+	assert.True(t, gr.Lobby.Empty())
+	assert.Equal(t, []string{"a", "b"}, gr.Lobby.Players)
+	prompt = playOut(t, gr, prompt)
+	assert.Equal(t, game.EndGame, prompt.Action)
+}
+
+func TestRejoin(t *testing.T) {
+	p := NewPandaGameEngine()
+	l := game.Lobby{Host: "a", Players: []string{"a", "b"}, Spectators: []string{"c"}, GameId: "g1"}
+	gr := &GameRecord{GID: "g1", Lobby: l}
+	types := func(events []framework.Event) []string {
+		out := make([]string, len(events))
+		for i, e := range events {
+			out[i] = e.Type
+		}
+		return out
+	}
+
+	events := p.rejoin(gr, "stranger")
+	assert.Equal(t, []string{string(protocol.Warning)}, types(events))
+
+	// in the lobby, the player gets the lobby again
+	events = p.rejoin(gr, "b")
+	assert.Equal(t, []string{"", string(protocol.LobbyUpdate)}, types(events))
+	assert.Equal(t, framework.TargetJoinGroup, events[0].Dest)
+	assert.Equal(t, "g1", events[0].DestId)
+
+	players := []game.Player{{ID: "a", Order: 1}, {ID: "b", Order: 2}}
+	gr.State = game.StartGame(players, game.WithSeed(2))
+	game.GameFlow(gr.State, game.PromptResponse{Action: game.NextPlayerTurn})
+	defer p.prompts.Stop("g1")
+	current := gr.State.CurrentTurn.PlayerID
+
+	// whoever's turn it is gets their prompt back, addressed to the game
+	events = p.rejoin(gr, current)
+	assert.Equal(t, []string{"", string(protocol.LobbyUpdate), string(protocol.GameUpdate), string(protocol.ActionPrompt)}, types(events))
+	prompt := events[3].Payload.(game.Prompt)
+	assert.Equal(t, gr.State.CurrentTurn.CurrentPrompt.Pid, prompt.Pid)
+	assert.Equal(t, "g1", prompt.Gid)
+	assert.Equal(t, current, events[3].DestId)
+	// only the copy sent out is addressed. the stored state changes through GameFlow alone, so its journal replays it
+	assert.Equal(t, "", gr.State.CurrentTurn.CurrentPrompt.Gid)
+
+	// everyone else only gets the game
+	events = p.rejoin(gr, "c")
+	assert.Equal(t, []string{"", string(protocol.LobbyUpdate), string(protocol.GameUpdate)}, types(events))
+
+	gr.Results = &game.GameResults{Winners: []string{"a"}}
+	events = p.rejoin(gr, current)
+	assert.Equal(t, []string{"", string(protocol.LobbyUpdate), string(protocol.GameUpdate), string(protocol.GameOver)}, types(events))
+}
+
+func TestPostChat(t *testing.T) {
+	gr := &GameRecord{GID: "g1", Lobby: game.Lobby{Players: []string{"a", "b"}, Spectators: []string{"s", "t"}}}
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	events, ok := postChat(gr, "stranger", "hi", now)
+	assert.False(t, ok)
+	assert.Equal(t, string(protocol.Warning), events[0].Type)
+
+	events, ok = postChat(gr, "a", "  good luck  ", now)
+	assert.True(t, ok)
+	assert.Equal(t, []game.ChatMessage{{From: "a", Message: "good luck", Gid: "g1", Timestamp: now}}, gr.ChatLog)
+	assert.Len(t, events, 1)
+	assert.Equal(t, framework.TargetGroup, events[0].Dest)
+	assert.Equal(t, string(protocol.ChatMessage), events[0].Type)
+
+	events, ok = postChat(gr, "b", "", now)
+	assert.False(t, ok)
+	assert.Equal(t, game.EmptyChatCode, events[0].Payload.(game.ValidationError).Code)
+	assert.Len(t, gr.ChatLog, 1)
+
+	// separated spectators only talk among themselves
+	gr.Lobby.Settings.SeparateSpectatorChat = true
+	events, ok = postChat(gr, "s", "a is going to lose", now)
+	assert.True(t, ok)
+	assert.True(t, gr.ChatLog[1].Spectator)
+	assert.Len(t, events, 2)
+	for i, e := range events {
+		assert.Equal(t, framework.TargetClient, e.Dest)
+		assert.Equal(t, gr.Lobby.Spectators[i], e.DestId)
+	}
+
+	page := chatHistory(gr, "a", game.ChatHistoryRequest{Gid: "g1"}).Payload.(game.ChatPage)
+	assert.Equal(t, gr.ChatLog[:1], page.Messages)
+	assert.Equal(t, "g1", page.Gid)
+	page = chatHistory(gr, "t", game.ChatHistoryRequest{Gid: "g1"}).Payload.(game.ChatPage)
+	assert.Equal(t, gr.ChatLog, page.Messages)
+	assert.Equal(t, string(protocol.Warning), chatHistory(gr, "stranger", game.ChatHistoryRequest{Gid: "g1"}).Type)
+}
+
+func TestPlayBotCantHang(t *testing.T) {
+	l := game.Lobby{Players: []string{"human"}}
+	l.AddBot(ai.RandomStrategy)
+	players := []game.Player{{ID: "human", Order: 1}, {ID: "bot:1", Order: 2}}
+	gr := &GameRecord{Lobby: l, State: game.StartGame(players, game.WithSeed(3))}
+	game.GameFlow(gr.State, game.PromptResponse{Action: game.NextPlayerTurn})
+	if gr.State.CurrentTurn.PlayerID != "bot:1" {
+		gr.State.NextTurn()
+	}
+
+	// a prompt nothing can answer, not even auto play. funcs are never equal, so the option never matches itself
+	gr.State.CurrentTurn.CurrentPrompt = game.Prompt{Action: game.ChooseGrowth, SelectFrom: []any{func() {}}, Pid: "stuck"}
+	prompt, ok := playBot(gr)
+	assert.True(t, ok)
+	assert.True(t, gr.State.GetPlayer("bot:1").Forfeited)
+	assert.Equal(t, game.EndGame, prompt.Action, "the person is the last one standing")
+}
+
+func TestJoinTwice(t *testing.T) {
+	p := NewPandaGameEngine()
+	gr := &GameRecord{GID: "g1", Lobby: game.Lobby{Host: "a", Players: []string{"a", "b", "c"}, GameId: "g1"}}
+
+	_, joined := p.join(gr, "d")
+	assert.True(t, joined)
+	_, joined = p.join(gr, "e")
+	assert.True(t, joined)
+	assert.Equal(t, []string{"a", "b", "c", "d"}, gr.Lobby.Players)
+	assert.Equal(t, []string{"e"}, gr.Lobby.Spectators)
+
+	// joining again, like after a refresh, catches the person up without another seat
+	for _, pid := range []string{"b", "e"} {
+		events, joined := p.join(gr, pid)
+		assert.False(t, joined)
+		assert.Equal(t, framework.TargetJoinGroup, events[0].Dest)
+		assert.Equal(t, string(protocol.LobbyUpdate), events[1].Type)
+		assert.Equal(t, pid, events[1].DestId)
+	}
+	assert.Equal(t, []string{"a", "b", "c", "d"}, gr.Lobby.Players)
+	assert.Equal(t, []string{"e"}, gr.Lobby.Spectators)
+}
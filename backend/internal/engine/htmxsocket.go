@@ -5,39 +5,58 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"pandagame/internal/game"
 	"pandagame/internal/htmx/websocket"
+	"pandagame/pkg/game"
+	"pandagame/pkg/protocol"
 )
 
 func SerializeToHTML(messageType string, payload any) (string, error) {
-	mt := ServerEventType(messageType)
+	mt := protocol.ServerEventType(messageType)
 	switch mt {
-	case LobbyUpdate:
+	case protocol.LobbyUpdate:
 		l, ok := payload.(game.Lobby)
 		if !ok {
 			return "", errors.New("bad lobby payload")
 		}
 		return serializeLobbyUpdate(l)
-	case GameStart, GameUpdate, GameOver:
+	case protocol.GameStart, protocol.GameUpdate:
 		g, ok := payload.(game.GameState)
 		if !ok {
 			return "", errors.New("bad game state payload")
 		}
 		return serializeGameState(g)
-	case ActionPrompt:
+	case protocol.GameOver:
+		r, ok := payload.(game.GameResults)
+		if !ok {
+			return "", errors.New("bad game results payload")
+		}
+		return serializeGameOver(r)
+	case protocol.ActionPrompt:
 		p, ok := payload.(game.Prompt)
 		if !ok {
 			return "", errors.New("bad prompt payload")
 		}
 		return serializeActionPrompt(p)
-	case Goodbye:
+	case protocol.ChatMessage:
+		m, ok := payload.(game.ChatMessage)
+		if !ok {
+			return "", errors.New("bad chat message payload")
+		}
+		return serializeChatMessage(m)
+	case protocol.ChatHistory:
+		p, ok := payload.(game.ChatPage)
+		if !ok {
+			return "", errors.New("bad chat history payload")
+		}
+		return serializeChatHistory(p)
+	case protocol.Goodbye:
 		return serializeGoodbye()
-	case Warning:
-		s, ok := payload.(string)
+	case protocol.Warning:
+		v, ok := payload.(game.ValidationError)
 		if !ok {
-			return "", errors.New("warning payload not a string")
+			return "", errors.New("bad warning payload")
 		}
-		return serializeWarning(s)
+		return serializeWarning(v)
 	default:
 		return "", fmt.Errorf("cannot serialize message type %s, not a server event type", messageType)
 	}
@@ -55,15 +74,33 @@ func serializeGameState(g game.GameState) (string, error) {
 	return bb.String(), err
 }
 
+func serializeGameOver(r game.GameResults) (string, error) {
+	bb := bytes.NewBuffer(make([]byte, 0))
+	err := websocket.RenderGameOver(r).Render(context.Background(), bb)
+	return bb.String(), err
+}
+
 func serializeActionPrompt(p game.Prompt) (string, error) {
 	bb := bytes.NewBuffer(make([]byte, 0))
 	err := websocket.RenderPrompt(p).Render(context.Background(), bb)
 	return bb.String(), err
 }
 
-func serializeWarning(message string) (string, error) {
+func serializeWarning(v game.ValidationError) (string, error) {
+	bb := bytes.NewBuffer(make([]byte, 0))
+	err := websocket.RenderWarning(v).Render(context.Background(), bb)
+	return bb.String(), err
+}
+
+func serializeChatMessage(m game.ChatMessage) (string, error) {
+	bb := bytes.NewBuffer(make([]byte, 0))
+	err := websocket.RenderChatMessage(m).Render(context.Background(), bb)
+	return bb.String(), err
+}
+
+func serializeChatHistory(p game.ChatPage) (string, error) {
 	bb := bytes.NewBuffer(make([]byte, 0))
-	err := websocket.RenderWarning(message).Render(context.Background(), bb)
+	err := websocket.RenderChatHistory(p).Render(context.Background(), bb)
 	return bb.String(), err
 }
 
@@ -7,35 +7,44 @@ import (
 	"log/slog"
 	"net/http"
 	"pandagame/internal/framework"
-	"pandagame/internal/game"
 	"pandagame/internal/web"
+	"pandagame/pkg/game"
+	"pandagame/pkg/protocol"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/mitchellh/mapstructure"
 )
 
 func MessageDeserializer(raw string, req *http.Request) (string, any, error) {
-	msg := new(ClientEventShell)
+	msg := new(protocol.ClientEventShell)
 	if err := json.NewDecoder(strings.NewReader(raw)).Decode(msg); err != nil {
 		return "", nil, err
 	}
 	var payload any
 	decodeJson := false
-	switch ClientEventType(msg.MessageType) {
-	case CreateGame, Matchmake, CancelMatchmake:
+	switch protocol.ClientEventType(msg.MessageType) {
+	case protocol.CreateGame, protocol.Matchmake, protocol.CancelMatchmake:
 		payload = ""
-	case JoinGame, LeaveGame, StartGame, Reprompt:
-		payload = string(msg.Message) // this is always the game id
-	case GameChat:
+	case protocol.JoinGame, protocol.LeaveGame, protocol.StartGame, protocol.Reprompt:
+		payload = gameIdMessage(msg.Message)
+	case protocol.GameChat:
 		payload = new(game.ChatMessage)
 		decodeJson = true
-	case TakeAction:
+	case protocol.FetchChat:
+		payload = new(game.ChatHistoryRequest)
+		decodeJson = true
+	case protocol.TakeAction:
 		payload = new(game.PromptResponse)
 		decodeJson = true
-	case ChangeSettings:
-		// TODO will be json
+	case protocol.ChangeSettings:
+		payload = new(game.SettingsChange)
+		decodeJson = true
+	case protocol.AddBot:
+		payload = new(game.BotRequest)
+		decodeJson = true
 	default:
 		return "", nil, fmt.Errorf("invalid message type: %s", msg.MessageType)
 	}
@@ -47,11 +56,20 @@ func MessageDeserializer(raw string, req *http.Request) (string, any, error) {
 	return msg.MessageType, payload, nil
 }
 
+// the message is always the game id. clients may send it as a json string or as the bare id
+func gameIdMessage(m json.RawMessage) string {
+	var id string
+	if err := json.Unmarshal(m, &id); err != nil {
+		return string(m)
+	}
+	return id
+}
+
 func MessageSerializer(messageType string, payload any, req *http.Request) (string, error) {
 	respType := chi.URLParam(req, "type")
 	switch respType {
 	case "json":
-		shell := ServerEventShell{
+		shell := protocol.ServerEventShell{
 			MessageType: messageType,
 			Message:     payload,
 		}
@@ -74,14 +92,22 @@ func MessageSerializer(messageType string, payload any, req *http.Request) (stri
 
 // ensure payloads contain specific structs for various event types
 func StructMiddleware(e framework.Event, r *http.Request) (framework.Event, error) {
-	mt := ServerEventType(e.Type)
+	mt := protocol.ServerEventType(e.Type)
 	switch mt {
-	case LobbyUpdate:
+	case protocol.LobbyUpdate:
 		e.Payload = structConverter[game.Lobby](e.Payload)
-	case GameStart, GameUpdate, GameOver:
+	case protocol.GameStart, protocol.GameUpdate:
 		e.Payload = structConverter[game.GameState](e.Payload)
-	case ActionPrompt:
+	case protocol.GameOver:
+		e.Payload = structConverter[game.GameResults](e.Payload)
+	case protocol.ActionPrompt:
 		e.Payload = structConverter[game.Prompt](e.Payload)
+	case protocol.Warning:
+		e.Payload = structConverter[game.ValidationError](e.Payload)
+	case protocol.ChatMessage:
+		e.Payload = structConverter[game.ChatMessage](e.Payload)
+	case protocol.ChatHistory:
+		e.Payload = structConverter[game.ChatPage](e.Payload)
 	default:
 
 	}
@@ -97,7 +123,13 @@ func structConverter[T any](payload any) T {
 		return payload.(T)
 	case reflect.Map:
 		out := new(T)
-		dcfg := &mapstructure.DecoderConfig{TagName: "json", IgnoreUntaggedFields: true, Result: out}
+		dcfg := &mapstructure.DecoderConfig{
+			TagName:              "json",
+			IgnoreUntaggedFields: true,
+			Result:               out,
+			// times come out of json as strings, like chat timestamps
+			DecodeHook: mapstructure.StringToTimeHookFunc(time.RFC3339Nano),
+		}
 		d, _ := mapstructure.NewDecoder(dcfg)
 		if err := d.Decode(payload); err != nil {
 			slog.Warn("failed to convert map to struct")
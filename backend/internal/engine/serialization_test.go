@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"encoding/json"
 	"pandagame/internal/framework"
-	"pandagame/internal/game"
+	"pandagame/pkg/game"
+	"pandagame/pkg/protocol"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -16,9 +18,13 @@ func TestSerializationLoop(t *testing.T) {
 		MsgType string
 		Payload any
 	}{
-		{"LobbyUpdate", game.Lobby{Host: "larry", Players: []string{"larry", "big bird"}}},
+		{"LobbyUpdate", game.Lobby{Host: "larry", Players: []string{"larry", "big bird"}, Settings: game.Settings{TimeBank: 600, Increment: 15, BankExpiry: game.ForfeitOnExpiry}, Bots: map[string]string{"bot:1": "greedy"}}},
 		{"GameUpdate", game.GameState{Board: &game.Board{Plots: map[string]game.Plot{"a": {Type: game.FuturePlot}}}}},
+		{"GameOver", game.GameResults{Standings: []game.PlayerResult{{PlayerID: "larry", Score: 12, Rank: 1}}, Winners: []string{"larry"}}},
 		{"ActionPrompt", game.Prompt{Action: game.ChooseGrowth, SelectType: game.PlotIDSelectType, SelectFrom: []any{"a", "b", "c"}}},
+		{"Warning", game.ValidationError{Code: game.NotYourTurnCode, Message: "it is not your turn"}},
+		{"ChatMessage", game.ChatMessage{From: "larry", Message: "hi", Gid: "g1", Timestamp: time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC), Spectator: true}},
+		{"ChatHistory", game.ChatPage{Gid: "g1", Messages: []game.ChatMessage{{From: "larry", Message: "hi", Timestamp: time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)}}, Start: 3, More: true}},
 	}
 	for _, tc := range cases {
 		t.Run(tc.MsgType, func(tt *testing.T) {
@@ -43,3 +49,35 @@ func TestStructConverter(t *testing.T) {
 	l3 := structConverter[game.Lobby](&l)
 	assert.Equal(t, l, l3)
 }
+
+func TestDeserializeChangeSettings(t *testing.T) {
+	raw := `{"messageType": "ChangeSettings", "message": {"gameId": "g1", "settings": {"timeBank": 300, "increment": 5, "bankExpiry": "FORFEIT"}}}`
+	mt, payload, err := MessageDeserializer(raw, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, string(protocol.ChangeSettings), mt)
+	change := structConverter[game.SettingsChange](payload)
+	assert.Equal(t, "g1", change.Gid)
+	assert.Equal(t, game.Settings{TimeBank: 300, Increment: 5, BankExpiry: game.ForfeitOnExpiry}, change.Settings)
+}
+
+func TestDeserializeGameId(t *testing.T) {
+	_, payload, err := MessageDeserializer(`{"messageType": "JoinGame", "message": "g1"}`, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "g1", payload)
+	// ids that aren't json strings are passed through as they are
+	_, payload, err = MessageDeserializer(`{"messageType": "StartGame", "message": 1234}`, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "1234", payload)
+}
+
+func TestDeserializeChat(t *testing.T) {
+	mt, payload, err := MessageDeserializer(`{"messageType": "GameChat", "message": {"gid": "g1", "message": "hello"}}`, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, string(protocol.GameChat), mt)
+	assert.Equal(t, game.ChatMessage{Gid: "g1", Message: "hello"}, structConverter[game.ChatMessage](payload))
+
+	mt, payload, err = MessageDeserializer(`{"messageType": "FetchChat", "message": {"gameId": "g1", "before": 40, "limit": 20}}`, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, string(protocol.FetchChat), mt)
+	assert.Equal(t, game.ChatHistoryRequest{Gid: "g1", Before: 40, Limit: 20}, structConverter[game.ChatHistoryRequest](payload))
+}
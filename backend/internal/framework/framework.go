@@ -209,7 +209,14 @@ func (f *Framework) handleEvent(e Event) error {
 	if err != nil {
 		return err
 	}
-	for _, event := range responseEvents {
+	f.Dispatch(responseEvents)
+	return nil
+}
+
+// routes events to their destinations. Engine responses are dispatched automatically,
+// but an engine can also dispatch events it produces on its own (such as from a timer)
+func (f *Framework) Dispatch(events []Event) {
+	for _, event := range events {
 		var msg RelayMessage
 		switch event.Dest {
 		case TargetClient:
@@ -241,8 +248,6 @@ func (f *Framework) handleEvent(e Event) error {
 			f.config.Relayer.Broadcast(msg)
 		}
 	}
-
-	return nil
 }
 
 func executeMiddlewares(e Event, r *http.Request, mws []Middleware) (Event, error) {
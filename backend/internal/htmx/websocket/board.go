@@ -0,0 +1,167 @@
+package websocket
+
+import (
+	"fmt"
+	"math"
+	"pandagame/pkg/game"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// distance from the center of a hex to its corners, in svg units
+const hexSize = 40.0
+
+type point struct {
+	X float64
+	Y float64
+}
+
+func (p point) String() string {
+	return fmt.Sprintf("%s,%s", num(p.X), num(p.Y))
+}
+
+type plotView struct {
+	ID          string
+	Class       string
+	Points      string
+	Center      point
+	Bamboo      int
+	Improvement string
+}
+
+type edgeView struct {
+	ID    string
+	Class string
+	From  point
+	To    point
+}
+
+// everything needed to draw the board, in svg coordinates
+type boardView struct {
+	ViewBox  string
+	Plots    []plotView
+	Edges    []edgeView
+	Panda    point
+	Gardener point
+}
+
+// svg numbers are rounded to a tenth, which is plenty at hexSize
+func num(f float64) string {
+	if math.Abs(f) < 0.05 {
+		f = 0 // no "-0.0"
+	}
+	return strconv.FormatFloat(f, 'f', 1, 64)
+}
+
+// the pixel center of a hex. hexes are pointy-top
+func hexCenter(h game.Hex) point {
+	return point{
+		X: hexSize * math.Sqrt(3) * (float64(h.Q) + float64(h.R)/2),
+		Y: hexSize * 1.5 * float64(h.R),
+	}
+}
+
+// the corner of a hex at the given angle from its center. Screen y points down, so angles run clockwise
+func hexCorner(c point, degrees float64) point {
+	rad := degrees * math.Pi / 180
+	return point{
+		X: c.X + hexSize*math.Cos(rad),
+		Y: c.Y + hexSize*math.Sin(rad),
+	}
+}
+
+// the two corners of edge index i. Edge i faces the neighbor in hex direction i, which is 60 degrees counter clockwise from edge i-1
+func edgeCorners(c point, i int) (point, point) {
+	facing := -60.0 * float64(i)
+	return hexCorner(c, facing-30), hexCorner(c, facing+30)
+}
+
+func plotClass(pt game.PlotType) string {
+	return strings.ToLower(string(pt))
+}
+
+func newBoardView(b *game.Board) boardView {
+	v := boardView{
+		Plots: make([]plotView, 0),
+		Edges: make([]edgeView, 0),
+	}
+	if b == nil {
+		return v
+	}
+	pids := make([]string, 0, len(b.Plots))
+	for pid := range b.Plots {
+		pids = append(pids, pid)
+	}
+	slices.Sort(pids)
+
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, pid := range pids {
+		p := b.Plots[pid]
+		c := hexCenter(p.Coord)
+		corners := make([]string, 6)
+		for k := 0; k < 6; k++ {
+			corner := hexCorner(c, 60*float64(k)-30)
+			corners[k] = corner.String()
+			minX, minY = min(minX, corner.X), min(minY, corner.Y)
+			maxX, maxY = max(maxX, corner.X), max(maxY, corner.Y)
+		}
+		class := "plot " + plotClass(p.Type)
+		if p.Type.IsBamboo() && b.PlotIsIrrigated(pid) {
+			class += " irrigated"
+		}
+		improvement := ""
+		if p.Improvement.Type != game.NoImprovement {
+			improvement = string(p.Improvement.Type)
+		}
+		v.Plots = append(v.Plots, plotView{
+			ID:          pid,
+			Class:       class,
+			Points:      strings.Join(corners, " "),
+			Center:      c,
+			Bamboo:      p.Bamboo,
+			Improvement: improvement,
+		})
+		if pid == b.PandaLocation {
+			v.Panda = c
+		}
+		if pid == b.GardenerLocation {
+			v.Gardener = c
+		}
+	}
+
+	eids := make([]string, 0, len(b.Edges))
+	for eid := range b.Edges {
+		eids = append(eids, eid)
+	}
+	slices.Sort(eids)
+	for _, eid := range eids {
+		e := b.Edges[eid]
+		p := b.Plots[e.Plots[0]]
+		other := b.Plots[e.Plots[1]]
+		// channels can only run between placed plots, so edges on the frontier are not drawn
+		if p.Type == game.FuturePlot || other.Type == game.FuturePlot {
+			continue
+		}
+		i := slices.Index(p.Edges[:], eid)
+		if i == -1 {
+			continue
+		}
+		from, to := edgeCorners(hexCenter(p.Coord), i)
+		class := "edge"
+		if e.Irrigated {
+			class += " irrigated"
+		}
+		v.Edges = append(v.Edges, edgeView{
+			ID:    eid,
+			Class: class,
+			From:  from,
+			To:    to,
+		})
+	}
+
+	pad := hexSize / 4
+	v.ViewBox = fmt.Sprintf("%s %s %s %s", num(minX-pad), num(minY-pad), num(maxX-minX+2*pad), num(maxY-minY+2*pad))
+	return v
+}
@@ -0,0 +1,87 @@
+package websocket
+
+import (
+	"math"
+	"pandagame/pkg/game"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func distance(a, b point) float64 {
+	return math.Hypot(a.X-b.X, a.Y-b.Y)
+}
+
+func TestNewBoardView(t *testing.T) {
+	empty := newBoardView(nil)
+	assert.Empty(t, empty.Plots)
+	assert.Empty(t, empty.Edges)
+
+	b := game.NewBoard()
+	b.AddPlot("p1", game.GreenBambooPlot, game.NoImprovement)
+	b.AddPlot("p2", game.YellowBambooPlot, game.EnclosureImprovement)
+	b.PandaLocation = "p2"
+	v := newBoardView(b)
+
+	plots := make(map[string]plotView)
+	for _, p := range v.Plots {
+		plots[p.ID] = p
+	}
+	// every plot is drawn under its own id, so any plot a prompt offers can be clicked
+	assert.Equal(t, len(b.Plots), len(plots))
+	for pid, p := range b.Plots {
+		assert.Equal(t, hexCenter(p.Coord), plots[pid].Center, pid)
+		assert.Equal(t, p.Bamboo, plots[pid].Bamboo, pid)
+		assert.Len(t, strings.Fields(plots[pid].Points), 6, pid)
+	}
+	assert.Equal(t, point{0, 0}, plots["p0"].Center)
+	assert.InDelta(t, hexSize*math.Sqrt(3), distance(plots["p0"].Center, plots["p1"].Center), 0.001)
+	assert.InDelta(t, hexSize*math.Sqrt(3), distance(plots["p1"].Center, plots["p2"].Center), 0.001)
+
+	assert.Equal(t, "plot pond", plots["p0"].Class)
+	assert.Equal(t, "plot green_bamboo irrigated", plots["p1"].Class)
+	assert.Equal(t, "plot yellow_bamboo irrigated", plots["p2"].Class)
+	assert.Equal(t, "plot future", plots["p3"].Class)
+	// placing next to the pond grew their first bamboo
+	assert.Equal(t, 1, plots["p1"].Bamboo)
+	assert.Equal(t, "", plots["p1"].Improvement)
+	assert.Equal(t, "ENCLOSURE", plots["p2"].Improvement)
+
+	assert.Equal(t, plots["p2"].Center, v.Panda)
+	assert.Equal(t, plots["p0"].Center, v.Gardener)
+
+	// only edges between placed plots are drawn: the pond's edges to p1 and p2, and the edge between them
+	drawn := make([]string, 0)
+	for eid, e := range b.Edges {
+		if b.Plots[e.Plots[0]].Type != game.FuturePlot && b.Plots[e.Plots[1]].Type != game.FuturePlot {
+			drawn = append(drawn, eid)
+		}
+	}
+	assert.Len(t, drawn, 3)
+	ids := make([]string, len(v.Edges))
+	for i, ev := range v.Edges {
+		ids[i] = ev.ID
+		e := b.Edges[ev.ID]
+		// the line is the side the two hexes share, so it is a hex side long and centered between them
+		a, c := hexCenter(b.Plots[e.Plots[0]].Coord), hexCenter(b.Plots[e.Plots[1]].Coord)
+		assert.InDelta(t, hexSize, distance(ev.From, ev.To), 0.001, ev.ID)
+		assert.InDelta(t, (a.X+c.X)/2, (ev.From.X+ev.To.X)/2, 0.001, ev.ID)
+		assert.InDelta(t, (a.Y+c.Y)/2, (ev.From.Y+ev.To.Y)/2, 0.001, ev.ID)
+		if e.Plots[0] == b.PondID || e.Plots[1] == b.PondID {
+			assert.Equal(t, "edge irrigated", ev.Class, ev.ID)
+		} else {
+			assert.Equal(t, "edge", ev.Class, ev.ID)
+		}
+	}
+	assert.ElementsMatch(t, drawn, ids)
+	// every edge an irrigation prompt can offer has a line to click
+	assert.NotEmpty(t, b.AllIrrigatableEdges())
+	for _, eid := range b.AllIrrigatableEdges() {
+		assert.Contains(t, ids, eid)
+	}
+
+	// the view box fits every corner, with some padding
+	assert.Len(t, strings.Fields(v.ViewBox), 4)
+	assert.True(t, strings.HasPrefix(v.ViewBox, "-"))
+}
@@ -0,0 +1,97 @@
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"pandagame/pkg/game"
+	"strconv"
+)
+
+// a choice the player can click. Selection is the json the choice is sent back to the server as
+type promptOption struct {
+	Label     string
+	Selection string
+}
+
+type plotCard struct {
+	promptOption
+	Class       string
+	Improvement string
+}
+
+type promptView struct {
+	Title    string
+	Action   string
+	PromptID string
+	GameID   string
+	Time     string
+	Buttons  []promptOption
+	Cards    []plotCard
+	// json list of the board element ids that can be clicked to answer the prompt
+	BoardTargets string
+}
+
+var promptTitles = map[game.PromptType]string{
+	game.RollDie:                      "Roll the weather die",
+	game.ChooseWeather:                "Choose the weather",
+	game.ChooseImprovementToUse:       "Choose an improvement to place",
+	game.ChooseImprovementToStash:     "Choose an improvement to keep",
+	game.ChooseGrowth:                 "Choose a plot to grow",
+	game.ChoosePandaDestination:       "Move the panda",
+	game.ChooseAction:                 "Choose an action",
+	game.ChoosePlot:                   "Choose a plot to place",
+	game.ChoosePlotDestination:        "Choose where to place the plot",
+	game.ChooseGardenerDestination:    "Move the gardener",
+	game.ChooseObjectiveType:          "Choose an objective to draw",
+	game.ChooseIrrigationDestination:  "Choose where to place the irrigation",
+	game.ChooseImprovementDestination: "Choose where to place the improvement",
+}
+
+func selectionJSON(s any) string {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return "null"
+	}
+	return string(b)
+}
+
+func newPromptView(p game.Prompt) promptView {
+	v := promptView{
+		Title:        promptTitles[p.Action],
+		Action:       string(p.Action),
+		PromptID:     p.Pid,
+		GameID:       p.Gid,
+		Time:         strconv.Itoa(p.Time),
+		Buttons:      make([]promptOption, 0),
+		Cards:        make([]plotCard, 0),
+		BoardTargets: "[]",
+	}
+	if v.Title == "" {
+		v.Title = string(p.Action)
+	}
+	switch p.SelectType {
+	case game.PlotSelectType:
+		for _, o := range p.SelectFrom {
+			dp := game.GetSelection(p.Action, o).(game.DeckPlot)
+			card := plotCard{
+				promptOption: promptOption{Label: string(dp.Type), Selection: selectionJSON(dp)},
+				Class:        "plot-card " + plotClass(dp.Type),
+			}
+			if dp.Improvement != game.NoImprovement && dp.Improvement != "" {
+				card.Improvement = string(dp.Improvement)
+			}
+			v.Cards = append(v.Cards, card)
+		}
+	case game.PlotIDSelectType, game.EdgeIDSelectType:
+		ids := make([]string, len(p.SelectFrom))
+		for i, o := range p.SelectFrom {
+			ids[i] = fmt.Sprint(o)
+		}
+		v.BoardTargets = selectionJSON(ids)
+	default:
+		for _, o := range p.SelectFrom {
+			v.Buttons = append(v.Buttons, promptOption{Label: fmt.Sprint(o), Selection: selectionJSON(o)})
+		}
+	}
+	return v
+}
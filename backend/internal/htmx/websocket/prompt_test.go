@@ -0,0 +1,106 @@
+package websocket
+
+import (
+	"encoding/json"
+	"pandagame/pkg/game"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPromptView(t *testing.T) {
+	plotIDs := []any{"p1", "p2"}
+	cases := []struct {
+		prompt  game.Prompt
+		buttons []string // labels
+		cards   []string // classes
+		targets string
+	}{
+		{game.Prompt{Action: game.RollDie, SelectType: game.RollSelectType, SelectFrom: []any{game.RollDie}}, []string{"RollDie"}, nil, "[]"},
+		{game.Prompt{Action: game.ChooseWeather, SelectType: game.WeatherSelectType, SelectFrom: []any{game.SunWeather, game.RainWeather}}, []string{"SUN", "RAIN"}, nil, "[]"},
+		{game.Prompt{Action: game.ChooseImprovementToUse, SelectType: game.ImprovementSelectType, SelectFrom: []any{game.WatershedImprovement}}, []string{"WATERSHED"}, nil, "[]"},
+		{game.Prompt{Action: game.ChooseImprovementToStash, SelectType: game.ImprovementSelectType, SelectFrom: []any{game.EnclosureImprovement, game.FertilizerImprovement}}, []string{"ENCLOSURE", "FERTILIZER"}, nil, "[]"},
+		{game.Prompt{Action: game.ChooseGrowth, SelectType: game.PlotIDSelectType, SelectFrom: plotIDs}, nil, nil, `["p1","p2"]`},
+		{game.Prompt{Action: game.ChoosePandaDestination, SelectType: game.PlotIDSelectType, SelectFrom: plotIDs}, nil, nil, `["p1","p2"]`},
+		{game.Prompt{Action: game.ChooseAction, SelectType: game.ActionSelectType, SelectFrom: []any{game.MovePanda, game.EndTurn}}, []string{string(game.MovePanda), string(game.EndTurn)}, nil, "[]"},
+		{game.Prompt{Action: game.ChoosePlot, SelectType: game.PlotSelectType, SelectFrom: []any{
+			game.DeckPlot{Type: game.GreenBambooPlot, Improvement: game.NoImprovement},
+			game.DeckPlot{Type: game.PinkBambooPlot, Improvement: game.WatershedImprovement},
+		}}, nil, []string{"plot-card green_bamboo", "plot-card pink_bamboo"}, "[]"},
+		{game.Prompt{Action: game.ChoosePlotDestination, SelectType: game.PlotIDSelectType, SelectFrom: plotIDs}, nil, nil, `["p1","p2"]`},
+		{game.Prompt{Action: game.ChooseGardenerDestination, SelectType: game.PlotIDSelectType, SelectFrom: plotIDs}, nil, nil, `["p1","p2"]`},
+		{game.Prompt{Action: game.ChooseObjectiveType, SelectType: game.ObjectiveSelectType, SelectFrom: []any{game.PandaObjectiveType, game.PlotObjectiveType}}, []string{string(game.PandaObjectiveType), string(game.PlotObjectiveType)}, nil, "[]"},
+		{game.Prompt{Action: game.ChooseIrrigationDestination, SelectType: game.EdgeIDSelectType, SelectFrom: []any{"e7"}}, nil, nil, `["e7"]`},
+		{game.Prompt{Action: game.ChooseImprovementDestination, SelectType: game.PlotIDSelectType, SelectFrom: plotIDs}, nil, nil, `["p1","p2"]`},
+	}
+	// every prompt a player can be sent has a case
+	assert.Len(t, cases, len(promptTitles))
+
+	for _, c := range cases {
+		c.prompt.Pid = "prompt"
+		c.prompt.Gid = "g1"
+		c.prompt.Time = 30
+		v := newPromptView(c.prompt)
+		assert.Equal(t, promptTitles[c.prompt.Action], v.Title)
+		assert.NotEmpty(t, v.Title)
+		assert.Equal(t, string(c.prompt.Action), v.Action)
+		assert.Equal(t, "prompt", v.PromptID)
+		assert.Equal(t, "g1", v.GameID)
+		assert.Equal(t, "30", v.Time)
+		assert.Equal(t, c.targets, v.BoardTargets, c.prompt.Action)
+
+		var labels, classes []string
+		for _, b := range v.Buttons {
+			labels = append(labels, b.Label)
+		}
+		for _, card := range v.Cards {
+			classes = append(classes, card.Class)
+		}
+		assert.Equal(t, c.buttons, labels, c.prompt.Action)
+		assert.Equal(t, c.cards, classes, c.prompt.Action)
+
+		// whatever the page sends back for a button, a card or a board element is accepted by the game
+		sent := make([]any, 0)
+		for _, b := range v.Buttons {
+			var s any
+			assert.Nil(t, json.Unmarshal([]byte(b.Selection), &s))
+			sent = append(sent, s)
+		}
+		for _, card := range v.Cards {
+			var s any
+			assert.Nil(t, json.Unmarshal([]byte(card.Selection), &s))
+			sent = append(sent, s)
+		}
+		var ids []string
+		assert.Nil(t, json.Unmarshal([]byte(v.BoardTargets), &ids))
+		for _, id := range ids {
+			sent = append(sent, id)
+		}
+		assert.Equal(t, len(c.prompt.SelectFrom), len(sent), c.prompt.Action)
+		g := game.NewGame()
+		g.CurrentTurn.CurrentPrompt = c.prompt
+		for _, s := range sent {
+			assert.Nil(t, g.ValidatePlayerAction(game.PromptResponse{Action: c.prompt.Action, Pid: "prompt", Gid: "g1", Selection: s}), "%s rejected %v", c.prompt.Action, s)
+		}
+	}
+}
+
+func TestPlotCards(t *testing.T) {
+	// a prompt loaded back from storage has its plots as maps
+	p := game.Prompt{Action: game.ChoosePlot, SelectType: game.PlotSelectType, SelectFrom: []any{
+		map[string]any{"type": "YELLOW_BAMBOO", "improvement": "ENCLOSURE"},
+		map[string]any{"type": "GREEN_BAMBOO", "improvement": "NONE"},
+	}}
+	v := newPromptView(p)
+	assert.Len(t, v.Cards, 2)
+	assert.Equal(t, "YELLOW_BAMBOO", v.Cards[0].Label)
+	assert.Equal(t, "ENCLOSURE", v.Cards[0].Improvement)
+	assert.Equal(t, `{"type":"YELLOW_BAMBOO","improvement":"ENCLOSURE"}`, v.Cards[0].Selection)
+	// a plot without an improvement doesn't show one
+	assert.Equal(t, "", v.Cards[1].Improvement)
+	assert.Empty(t, v.Buttons)
+	assert.Equal(t, "[]", v.BoardTargets)
+
+	// a prompt this client has no title for still says what it is
+	assert.Equal(t, "Mystery", newPromptView(game.Prompt{Action: "Mystery"}).Title)
+}
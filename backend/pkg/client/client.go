@@ -0,0 +1,235 @@
+// Package client speaks the panda game's json websocket protocol, for bots, load tests and terminal clients
+package client
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"pandagame/pkg/game"
+	"pandagame/pkg/protocol"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// callbacks for the events the server sends. they are called from Run, one at a time, in the order the events arrive
+type Handlers struct {
+	LobbyUpdate  func(game.Lobby)
+	GameStart    func(game.ClientGameState)
+	GameUpdate   func(game.ClientGameState)
+	ActionPrompt func(game.Prompt)
+	GameOver     func(game.GameResults)
+	Warning      func(game.ValidationError)
+	ChatMessage  func(game.ChatMessage)
+	ChatHistory  func(game.ChatPage)
+	Goodbye      func()
+}
+
+// the server's event shell, with the message left raw until the type is known
+type serverEvent struct {
+	MessageType protocol.ServerEventType `json:"messageType"`
+	Message     json.RawMessage          `json:"message"`
+}
+
+type Client struct {
+	conn     *websocket.Conn
+	id       string
+	handlers *Handlers
+	// handlers may send while Run is reading, and gorilla allows only one writer at a time
+	writeLock sync.Mutex
+}
+
+// the cookie the server's login form sets the token in
+const tokenCookie = "PGToken"
+
+// signs in through the login form of the server at addr (host:port) and returns the token to dial with
+func Login(addr, username, password string) (string, error) {
+	u := url.URL{Scheme: "http", Host: addr, Path: "/hmx/login"}
+	resp, err := http.PostForm(u.String(), url.Values{"username": {username}, "password": {password}})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("login failed: %s", resp.Status)
+	}
+	for _, c := range resp.Cookies() {
+		if c.Name == tokenCookie && c.Value != "" {
+			return c.Value, nil
+		}
+	}
+	return "", errors.New("login failed: no token in the response")
+}
+
+// the ID claim of a jwt. the server checks the signature, the client only needs to know who it is
+func idFromToken(token string) string {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return ""
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return ""
+	}
+	claims := struct {
+		ID string `json:"ID"`
+	}{}
+	if err := json.Unmarshal(raw, &claims); err != nil {
+		return ""
+	}
+	return claims.ID
+}
+
+// connects to the json websocket of the server at addr (host:port)
+func Dial(addr, token string) (*Client, error) {
+	u := url.URL{Scheme: "ws", Host: addr, Path: "/wss/json"}
+	header := http.Header{}
+	header.Set("Authorization", token)
+	conn, _, err := websocket.DefaultDialer.Dial(u.String(), header)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		conn:     conn,
+		id:       idFromToken(token),
+		handlers: &Handlers{},
+	}, nil
+}
+
+func (c *Client) Configure(cfgs ...func(*Handlers)) {
+	for _, fn := range cfgs {
+		fn(c.handlers)
+	}
+}
+
+// the player id the server knows this client by
+func (c *Client) ID() string {
+	return c.id
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// reads events and calls their handlers until the connection closes. a normal close returns nil
+func (c *Client) Run() error {
+	for {
+		var e serverEvent
+		if err := c.conn.ReadJSON(&e); err != nil {
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		if err := c.handle(e); err != nil {
+			slog.Warn("could not decode server event", slog.String("type", string(e.MessageType)), slog.String("error", err.Error()))
+		}
+	}
+}
+
+func (c *Client) handle(e serverEvent) error {
+	h := c.handlers
+	switch e.MessageType {
+	case protocol.LobbyUpdate:
+		return dispatch(e.Message, h.LobbyUpdate)
+	case protocol.GameStart:
+		return dispatch(e.Message, h.GameStart)
+	case protocol.GameUpdate:
+		return dispatch(e.Message, h.GameUpdate)
+	case protocol.ActionPrompt:
+		return dispatch(e.Message, h.ActionPrompt)
+	case protocol.GameOver:
+		return dispatch(e.Message, h.GameOver)
+	case protocol.Warning:
+		return dispatch(e.Message, h.Warning)
+	case protocol.ChatMessage:
+		return dispatch(e.Message, h.ChatMessage)
+	case protocol.ChatHistory:
+		return dispatch(e.Message, h.ChatHistory)
+	case protocol.Goodbye:
+		if h.Goodbye != nil {
+			h.Goodbye()
+		}
+	}
+	return nil
+}
+
+// decodes the message and passes it to the handler, if there is one
+func dispatch[T any](raw json.RawMessage, handler func(T)) error {
+	if handler == nil {
+		return nil
+	}
+	var t T
+	if err := json.Unmarshal(raw, &t); err != nil {
+		return err
+	}
+	handler(t)
+	return nil
+}
+
+func (c *Client) send(mt protocol.ClientEventType, message any) error {
+	raw, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	return c.conn.WriteJSON(protocol.ClientEventShell{MessageType: string(mt), Message: raw})
+}
+
+// creates a new game hosted by this client. the server answers with a LobbyUpdate
+func (c *Client) CreateGame() error {
+	return c.send(protocol.CreateGame, "")
+}
+
+func (c *Client) JoinGame(gameId string) error {
+	return c.send(protocol.JoinGame, gameId)
+}
+
+func (c *Client) StartGame(gameId string) error {
+	return c.send(protocol.StartGame, gameId)
+}
+
+func (c *Client) LeaveGame(gameId string) error {
+	return c.send(protocol.LeaveGame, gameId)
+}
+
+// asks the server to send the game and the current prompt again
+func (c *Client) Reprompt(gameId string) error {
+	return c.send(protocol.Reprompt, gameId)
+}
+
+func (c *Client) ChangeSettings(gameId string, s game.Settings) error {
+	return c.send(protocol.ChangeSettings, game.SettingsChange{Gid: gameId, Settings: s})
+}
+
+// fills an empty seat with a computer player using the named strategy. only the host can add bots
+func (c *Client) AddBot(gameId, strategy string) error {
+	return c.send(protocol.AddBot, game.BotRequest{Gid: gameId, Strategy: strategy})
+}
+
+// sends a message to everyone in the game
+func (c *Client) Chat(gameId, message string) error {
+	return c.send(protocol.GameChat, game.ChatMessage{Gid: gameId, Message: message})
+}
+
+// asks for the chat messages sent before position before, at most limit of them. 0 and 0 asks for the latest page
+func (c *Client) FetchChat(gameId string, before, limit int) error {
+	return c.send(protocol.FetchChat, game.ChatHistoryRequest{Gid: gameId, Before: before, Limit: limit})
+}
+
+// answers the prompt with one of its options
+func (c *Client) Respond(p game.Prompt, selection any) error {
+	return c.send(protocol.TakeAction, game.PromptResponse{
+		Action:    p.Action,
+		Selection: selection,
+		Pid:       p.Pid,
+		Gid:       p.Gid,
+	})
+}
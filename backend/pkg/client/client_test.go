@@ -0,0 +1,150 @@
+package client
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"pandagame/internal/engine"
+	"pandagame/pkg/game"
+	"pandagame/pkg/protocol"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+// a token with only the claims the client reads
+func testToken(id string) string {
+	claims := base64.RawURLEncoding.EncodeToString([]byte(`{"ID":"` + id + `"}`))
+	return "header." + claims + ".signature"
+}
+
+// serves /wss/{type} and answers every message with the events from respond, encoded the way the server encodes them
+func testServer(t *testing.T, respond func(messageType string, payload any) map[protocol.ServerEventType]any) *httptest.Server {
+	r := chi.NewRouter()
+	upgrader := websocket.Upgrader{}
+	r.Get("/wss/{type}", func(w http.ResponseWriter, req *http.Request) {
+		conn, err := upgrader.Upgrade(w, req, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			_, raw, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			mt, payload, err := engine.MessageDeserializer(string(raw), req)
+			assert.Nil(t, err)
+			for st, p := range respond(mt, payload) {
+				out, err := engine.MessageSerializer(string(st), p, req)
+				assert.Nil(t, err)
+				conn.WriteMessage(websocket.TextMessage, []byte(out))
+			}
+			if mt == string(protocol.LeaveGame) {
+				conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				return
+			}
+		}
+	})
+	return httptest.NewServer(r)
+}
+
+func TestClientProtocol(t *testing.T) {
+	prompt := game.Prompt{Action: game.ChooseAction, SelectType: game.ActionSelectType, SelectFrom: []any{"PlacePlot", "EndTurn"}, Pid: "prompt1", Gid: "g1"}
+	var received []game.PromptResponse
+	server := testServer(t, func(mt string, payload any) map[protocol.ServerEventType]any {
+		switch protocol.ClientEventType(mt) {
+		case protocol.JoinGame:
+			return map[protocol.ServerEventType]any{protocol.LobbyUpdate: game.Lobby{Host: "player:a", Players: []string{"player:a", "player:b"}, GameId: payload.(string)}}
+		case protocol.StartGame:
+			return map[protocol.ServerEventType]any{protocol.ActionPrompt: prompt}
+		case protocol.GameChat:
+			m := *payload.(*game.ChatMessage)
+			m.From = "player:b"
+			return map[protocol.ServerEventType]any{protocol.ChatMessage: m}
+		case protocol.FetchChat:
+			r := *payload.(*game.ChatHistoryRequest)
+			return map[protocol.ServerEventType]any{protocol.ChatHistory: game.ChatPage{Gid: r.Gid, Start: r.Before - r.Limit}}
+		case protocol.TakeAction:
+			received = append(received, *payload.(*game.PromptResponse))
+			if len(received) == 1 {
+				return map[protocol.ServerEventType]any{protocol.Warning: game.ValidationError{Code: game.InvalidSelectionCode, Message: "try again"}}
+			}
+			return map[protocol.ServerEventType]any{protocol.GameOver: game.GameResults{Winners: []string{"player:b"}}}
+		}
+		return nil
+	})
+	defer server.Close()
+
+	c, err := Dial(strings.TrimPrefix(server.URL, "http://"), testToken("player:b"))
+	assert.Nil(t, err)
+	assert.Equal(t, "player:b", c.ID())
+	var lobby game.Lobby
+	var results game.GameResults
+	var warning game.ValidationError
+	var chat game.ChatMessage
+	var history game.ChatPage
+	c.Configure(func(h *Handlers) {
+		h.LobbyUpdate = func(l game.Lobby) {
+			lobby = l
+			c.Chat(l.GameId, "hello")
+		}
+		h.ChatMessage = func(m game.ChatMessage) {
+			chat = m
+			c.FetchChat(m.Gid, 30, 10)
+		}
+		h.ChatHistory = func(p game.ChatPage) {
+			history = p
+			c.StartGame(p.Gid)
+		}
+		h.ActionPrompt = func(p game.Prompt) {
+			c.Respond(p, p.SelectFrom[0])
+		}
+		h.Warning = func(v game.ValidationError) {
+			warning = v
+			c.Respond(prompt, prompt.SelectFrom[1])
+		}
+		h.GameOver = func(r game.GameResults) {
+			results = r
+			c.LeaveGame(lobby.GameId)
+		}
+	})
+	assert.Nil(t, c.JoinGame("g1"))
+	assert.Nil(t, c.Run())
+
+	assert.Equal(t, "g1", lobby.GameId)
+	assert.Equal(t, []game.PromptResponse{
+		{Action: game.ChooseAction, Selection: "PlacePlot", Pid: "prompt1", Gid: "g1"},
+		{Action: game.ChooseAction, Selection: "EndTurn", Pid: "prompt1", Gid: "g1"},
+	}, received)
+	assert.Equal(t, game.ValidationError{Code: game.InvalidSelectionCode, Message: "try again"}, warning)
+	assert.Equal(t, []string{"player:b"}, results.Winners)
+	assert.Equal(t, game.ChatMessage{From: "player:b", Gid: "g1", Message: "hello"}, chat)
+	assert.Equal(t, game.ChatPage{Gid: "g1", Start: 20}, history)
+}
+
+func TestLogin(t *testing.T) {
+	r := chi.NewRouter()
+	r.Post("/hmx/login", func(w http.ResponseWriter, req *http.Request) {
+		req.ParseForm()
+		if req.PostForm.Get("username") != "b" || req.PostForm.Get("password") != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "PGToken", Value: testToken("player:b"), Secure: true, HttpOnly: true, Path: "/"})
+	})
+	server := httptest.NewServer(r)
+	defer server.Close()
+	addr := strings.TrimPrefix(server.URL, "http://")
+
+	token, err := Login(addr, "b", "secret")
+	assert.Nil(t, err)
+	assert.Equal(t, "player:b", idFromToken(token))
+
+	_, err = Login(addr, "b", "wrong")
+	assert.NotNil(t, err)
+	assert.Equal(t, "", idFromToken("not a token"))
+}
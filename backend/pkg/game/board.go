@@ -1,6 +1,7 @@
 package game
 
 import (
+	"encoding/json"
 	"fmt"
 	"math"
 	"slices"
@@ -14,6 +15,20 @@ type Board struct {
 	GardenerLocation string          `json:"gardenerLocation"`
 	PlotCount        int             `json:"plotCount"`
 	EdgeCount        int             `json:"edgeCount"`
+	// bamboo available to grow. nil means growth is unlimited
+	supply BambooReserve
+	// plot ids by coordinates. kept up to date as plots are added, so reading it is safe from any goroutine
+	coords map[Hex]string
+}
+
+// rebuilds the coordinate index, since it is not part of the board's json
+func (b *Board) UnmarshalJSON(data []byte) error {
+	type board Board
+	if err := json.Unmarshal(data, (*board)(b)); err != nil {
+		return err
+	}
+	b.indexCoords()
+	return nil
 }
 
 type PlotType string
@@ -32,6 +47,11 @@ func (p *PlotType) UnmarshalText(b []byte) error {
 	return nil
 }
 
+// true for the plot types that grow bamboo
+func (p PlotType) IsBamboo() bool {
+	return p == GreenBambooPlot || p == YellowBambooPlot || p == PinkBambooPlot
+}
+
 type ImprovementType string
 
 const (
@@ -71,6 +91,7 @@ type Plot struct {
 	Improvement Improvement `json:"improvement"`
 	Bamboo      int         `json:"bambooHeight"`
 	ID          string      `json:"id"`
+	Coord       Hex         `json:"coord"`
 }
 
 func (b *Board) NextPlotID() string {
@@ -120,21 +141,66 @@ func (b *Board) PlotIsIrrigated(pid string) bool {
 }
 
 func (b *Board) PlotAddImprovement(pid string, it ImprovementType) {
+	irrigated := b.irrigatedPlots()
 	p := b.Plots[pid]
 	p.Improvement = Improvement{it, false}
 	b.Plots[pid] = p
+	b.growNewlyIrrigated(irrigated)
 }
 
-func (b *Board) PlotGrowBamboo(pid string) {
+// the set of bamboo plots that are irrigated right now
+func (b *Board) irrigatedPlots() map[string]bool {
+	irrigated := make(map[string]bool)
+	for pid, p := range b.Plots {
+		if p.Type.IsBamboo() && b.PlotIsIrrigated(pid) {
+			irrigated[pid] = true
+		}
+	}
+	return irrigated
+}
+
+// a plot grows its first section of bamboo as soon as it is irrigated.
+// compares against the plots that were irrigated before a change to the board and grows the ones that are newly irrigated
+func (b *Board) growNewlyIrrigated(before map[string]bool) []string {
+	grown := make([]string, 0)
+	for pid := range b.irrigatedPlots() {
+		if before[pid] {
+			continue
+		}
+		if b.PlotGrowBamboo(pid) > 0 {
+			grown = append(grown, pid)
+		}
+	}
+	slices.Sort(grown)
+	return grown
+}
+
+// limits growth to the bamboo left in the supply. Growth takes bamboo from the supply, so pass the game's supply, not a copy
+func (b *Board) SetBambooSupply(s BambooReserve) {
+	b.supply = s
+}
+
+// grows the plot's bamboo and returns how many sections grew.
+// Nothing grows if the plot is not irrigated, is full, or the supply is out of its color
+func (b *Board) PlotGrowBamboo(pid string) int {
 	p := b.Plots[pid]
-	if !b.PlotIsIrrigated(pid) || p.Bamboo == 4 {
-		return
+	if !p.Type.IsBamboo() || !b.PlotIsIrrigated(pid) || p.Bamboo == 4 {
+		return 0
 	}
+	growth := 1
 	if p.Bamboo < 3 && p.Improvement.Type == FertilizerImprovement {
-		p.Bamboo++ // extra growth
+		growth++ // extra growth
+	}
+	if b.supply != nil {
+		growth = min(growth, b.supply[p.Type])
+		if growth == 0 {
+			return 0
+		}
+		b.supply[p.Type] -= growth
 	}
-	p.Bamboo++
+	p.Bamboo += growth
 	b.Plots[pid] = p
+	return growth
 }
 
 func (b *Board) PlotEatBamboo(pid string) PlotType {
@@ -147,34 +213,119 @@ func (b *Board) PlotEatBamboo(pid string) PlotType {
 	return p.Type
 }
 
-func (b *Board) EdgeCouldBeIrrigated(eid string) bool {
-	e := b.Edges[eid]
-	if e.Irrigated {
+// edges that share a corner with the edge. An irrigation channel can only be laid from the corner of another channel
+func (b *Board) edgeNeighbors(eid string) []string {
+	neighbors := make([]string, 0, 4)
+	for _, pid := range b.Edges[eid].Plots {
+		p, ok := b.Plots[pid]
+		if !ok {
+			continue
+		}
+		i := slices.Index(p.Edges[:], eid)
+		if i == -1 {
+			continue
+		}
+		for _, dx := range []int{1, -1} {
+			if adjacent := p.Edges[edgeIndex(i+dx)]; adjacent != "" {
+				neighbors = append(neighbors, adjacent)
+			}
+		}
+	}
+	return neighbors
+}
+
+// the irrigated edges that connect back to the pond. Irrigated edges that were somehow cut off from the pond are not included
+func (b *Board) IrrigationNetwork() map[string]bool {
+	network := make(map[string]bool)
+	queue := make([]string, 0)
+	for _, eid := range b.Plots[b.PondID].Edges {
+		if b.Edges[eid].Irrigated {
+			network[eid] = true
+			queue = append(queue, eid)
+		}
+	}
+	for len(queue) > 0 {
+		eid := queue[0]
+		queue = queue[1:]
+		for _, n := range b.edgeNeighbors(eid) {
+			if !network[n] && b.Edges[n].Irrigated {
+				network[n] = true
+				queue = append(queue, n)
+			}
+		}
+	}
+	return network
+}
+
+// true if a channel could be placed on the edge: both plots are placed, and it extends the network
+func (b *Board) edgeCanCarryIrrigation(eid string) bool {
+	e, ok := b.Edges[eid]
+	if !ok || e.Irrigated {
 		return false
 	}
-	p1 := b.Plots[e.Plots[0]]
-	p2 := b.Plots[e.Plots[1]]
-	if p1.Type == FuturePlot || p2.Type == FuturePlot {
+	for _, pid := range e.Plots {
+		if b.Plots[pid].Type == FuturePlot {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *Board) EdgeCouldBeIrrigated(eid string) bool {
+	if !b.edgeCanCarryIrrigation(eid) {
 		return false
 	}
-	for _, p := range []Plot{p1, p2} {
+	network := b.IrrigationNetwork()
+	return slices.ContainsFunc(b.edgeNeighbors(eid), func(n string) bool {
+		return network[n]
+	})
+}
 
-		i := slices.Index(p.Edges[:], eid)
-		for _, dx := range []int{1, -1} {
-			adjacentEdgeId := p.Edges[edgeIndex(i+dx)]
-			adjacentEdge := b.Edges[adjacentEdgeId]
-			if adjacentEdge.Irrigated {
-				return true
+// the edges that could be irrigated by laying at most n more channels, mapped to how many channels it takes.
+// 1 channel reaches the edges that can be irrigated now
+func (b *Board) EdgesWithinChannels(n int) map[string]int {
+	distance := make(map[string]int)
+	queue := make([]string, 0)
+	for eid := range b.IrrigationNetwork() {
+		distance[eid] = 0
+		queue = append(queue, eid)
+	}
+	// sort the starting edges so the search visits edges in the same order every time
+	slices.Sort(queue)
+	for len(queue) > 0 {
+		eid := queue[0]
+		queue = queue[1:]
+		if distance[eid] >= n {
+			continue
+		}
+		for _, next := range b.edgeNeighbors(eid) {
+			if _, seen := distance[next]; seen || !b.edgeCanCarryIrrigation(next) {
+				continue
 			}
+			distance[next] = distance[eid] + 1
+			queue = append(queue, next)
 		}
 	}
-	return false
+	reachable := make(map[string]int)
+	for eid, d := range distance {
+		if d > 0 {
+			reachable[eid] = d
+		}
+	}
+	return reachable
 }
 
-func (b *Board) EdgeAddIrrigation(eid string) {
+// irrigates the edge. The edge must extend the irrigation network from the pond
+func (b *Board) EdgeAddIrrigation(eid string) error {
+	if !b.EdgeCouldBeIrrigated(eid) {
+		return fmt.Errorf("edge %s does not extend the irrigation network", eid)
+	}
+	irrigated := b.irrigatedPlots()
 	e := b.Edges[eid]
 	e.Irrigated = true
 	b.Edges[eid] = e
+	b.growNewlyIrrigated(irrigated)
+	return nil
 }
 
 // returns AnyType plot if no bamboo is eaten
@@ -183,31 +334,48 @@ func (b *Board) MovePanda(pid string) PlotType {
 	return b.PlotEatBamboo(pid) // needs to place bamboo in player's inventory
 }
 
-func (b *Board) MoveGardener(pid string) {
+// the gardener grows bamboo on its destination and on every adjacent irrigated plot of the same color.
+// returns the ids of the plots that grew, destination first
+func (b *Board) MoveGardener(pid string) []string {
 	b.GardenerLocation = pid
-	b.PlotGrowBamboo(pid)
+	grown := make([]string, 0)
+	if b.PlotGrowBamboo(pid) > 0 {
+		grown = append(grown, pid)
+	}
+	color := b.Plots[pid].Type
+	if !color.IsBamboo() {
+		return grown
+	}
+	for i := 0; i < 6; i++ {
+		neighbor := b.PlotNeighbor(pid, i)
+		if neighbor == nil || neighbor.Type != color {
+			continue
+		}
+		if b.PlotGrowBamboo(neighbor.ID) > 0 {
+			grown = append(grown, neighbor.ID)
+		}
+	}
+	return grown
 }
 
-// returns all the tiles is a row in the direction of eidx (edge index)
+// returns all the tiles is a row in the direction of eidx (edge index), starting at pid, farthest first
 // useful for building a list of all legal moves for panda and garnder moves
 func (b *Board) TileIDsInRow(pid string, eidx int) []string {
-	p := b.Plots[pid]
-	if p.Type == FuturePlot {
+	if b.Plots[pid].Type == FuturePlot {
 		return []string{}
 	}
-	next := b.PlotNeighbor(pid, eidx)
-	if next == nil {
-		return []string{pid}
-	}
-	return append(b.TileIDsInRow(next.ID, eidx), pid)
+	row := b.Line(pid, eidx)
+	slices.Reverse(row)
+	return append(row, pid)
 }
 
 // gets all tiles in a straight line from the given plot that the gardener or panda could move to from the specified plot
 func (b *Board) LegalMovesFromPlot(pid string) []string {
 	plotIds := make([]string, 0)
 	for i := 0; i < 6; i++ {
-		nextPlot := b.PlotNeighbor(pid, i)
-		plotIds = append(plotIds, b.TileIDsInRow(nextPlot.ID, i)...)
+		line := b.Line(pid, i)
+		slices.Reverse(line)
+		plotIds = append(plotIds, line...)
 	}
 	return plotIds
 }
@@ -220,6 +388,7 @@ func (b *Board) AllPresentPlots() []string {
 			plotIDs = append(plotIDs, pid)
 		}
 	}
+	slices.Sort(plotIDs)
 	return plotIDs
 }
 
@@ -232,6 +401,7 @@ func (b *Board) AllIrrigatedPlots() []string {
 			plotIDs = append(plotIDs, pid)
 		}
 	}
+	slices.Sort(plotIDs)
 	return plotIDs
 }
 
@@ -243,6 +413,7 @@ func (b *Board) AllFuturePlots() []string {
 			plotIDs = append(plotIDs, pid)
 		}
 	}
+	slices.Sort(plotIDs)
 	return plotIDs
 }
 
@@ -254,21 +425,23 @@ func (b *Board) AllImprovablePlots() []string {
 			plotIDs = append(plotIDs, pid)
 		}
 	}
+	slices.Sort(plotIDs)
 	return plotIDs
 }
 
 // gets all ids of edges that are not irrigated but could be irrigated
 func (b *Board) AllIrrigatableEdges() []string {
 	edgeIDs := make([]string, 0)
-	for eid := range b.Edges {
-		if b.EdgeCouldBeIrrigated(eid) {
-			edgeIDs = append(edgeIDs, eid)
-		}
+	for eid := range b.EdgesWithinChannels(1) {
+		edgeIDs = append(edgeIDs, eid)
 	}
+	slices.Sort(edgeIDs)
 	return edgeIDs
 }
 
 func (b *Board) AddPlot(pid string, pt PlotType, it ImprovementType) {
+	irrigated := b.irrigatedPlots()
+	defer b.growNewlyIrrigated(irrigated)
 	f := b.Plots[pid]
 	p := Plot{
 		Type: pt,
@@ -278,6 +451,7 @@ func (b *Board) AddPlot(pid string, pt PlotType, it ImprovementType) {
 		},
 		Edges: f.Edges,
 		ID:    pid,
+		Coord: f.Coord,
 	}
 
 	b.Plots[pid] = p // replace future plot
@@ -329,7 +503,8 @@ func (b *Board) AddPlot(pid string, pt PlotType, it ImprovementType) {
 				Type:      NoImprovement,
 				Permanent: true,
 			},
-			ID: futurePid,
+			ID:    futurePid,
+			Coord: p.Coord.Neighbor(adjacentEdgeIdx),
 		}
 		for j := 0; j < 3; j++ {
 			newEdgeID := b.NextEdgeID()
@@ -356,7 +531,7 @@ func (b *Board) AddPlot(pid string, pt PlotType, it ImprovementType) {
 			adjacentEdgeIdx = edgeIndex(adjacentEdgeIdx + edgeIdxStep)
 			nextEdgeIdx = edgeIndex(nextEdgeIdx + edgeIdxStep)
 		}
-		b.Plots[futurePid] = futurePlot
+		b.placePlot(futurePlot)
 		p = b.Plots[pid]
 	}
 
@@ -385,7 +560,7 @@ func NewBoard() *Board {
 		Edges: [6]string(pondEdgeIDs),
 	}
 
-	b.Plots[b.PondID] = pond
+	b.placePlot(pond)
 
 	var futurePlotIDs [6]string
 	for i := 0; i < 6; i++ {
@@ -424,9 +599,10 @@ func NewBoard() *Board {
 			},
 			ID:    futurePlotIDs[i],
 			Edges: edges,
+			Coord: pond.Coord.Neighbor(i),
 		}
 
-		b.Plots[futurePlotIDs[i]] = future
+		b.placePlot(future)
 	}
 
 	return b
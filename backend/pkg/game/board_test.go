@@ -146,18 +146,18 @@ func TestAllImprovablePlots(t *testing.T) {
 	plots := b.AllImprovablePlots()
 	// pond and future plots are not improvable
 	assert.Equal(t, 0, len(plots))
-	// p1 has no bamboo or improvement, so it is improvable
+	// p1 grows bamboo as soon as it is placed next to the pond, which makes it ineligible for improvement
 	b.AddPlot("p1", GreenBambooPlot, NoImprovement)
 	plots = b.AllImprovablePlots()
-	assert.Equal(t, 1, len(plots))
-	// adding bamboo to p1 makes it ineligible for improvement
-	b.PlotGrowBamboo("p1")
-	plots = b.AllImprovablePlots()
 	assert.Equal(t, 0, len(plots))
 	// p2 has an improvement, so it is also ineligible
 	b.AddPlot("p2", YellowBambooPlot, FertilizerImprovement)
 	plots = b.AllImprovablePlots()
 	assert.Equal(t, 0, len(plots))
+	// p7 is not irrigated, so it has no bamboo or improvement and is improvable
+	b.AddPlot("p7", PinkBambooPlot, NoImprovement)
+	plots = b.AllImprovablePlots()
+	assert.Equal(t, []string{"p7"}, plots)
 }
 
 func TestIrrigatableEdges(t *testing.T) {
@@ -180,25 +180,73 @@ func TestIrrigatableEdges(t *testing.T) {
 	assert.Equal(t, 2, len(irrigatableEdges))
 }
 
+func TestIrrigationNetwork(t *testing.T) {
+	b := NewBoard()
+	b.AddPlot("p1", GreenBambooPlot, NoImprovement)
+	b.AddPlot("p2", YellowBambooPlot, NoImprovement)
+	b.AddPlot("p7", PinkBambooPlot, NoImprovement)
+	b.AddPlot("p8", GreenBambooPlot, NoImprovement)
+	// the pond's edges are the source of the network
+	assert.Equal(t, 6, len(b.IrrigationNetwork()))
+
+	// e12 (p2 to p7) doesn't touch the network until e6 (p1 to p2) is irrigated
+	assert.NotNil(t, b.EdgeAddIrrigation("e12"))
+	assert.False(t, b.Edges["e12"].Irrigated)
+	assert.Nil(t, b.EdgeAddIrrigation("e6"))
+	assert.Nil(t, b.EdgeAddIrrigation("e12"))
+	assert.True(t, b.IrrigationNetwork()["e12"])
+	// irrigated edges can't be irrigated again
+	assert.NotNil(t, b.EdgeAddIrrigation("e6"))
+
+	// an irrigated edge that is cut off from the pond is not part of the network
+	e := b.Edges["e18"]
+	e.Irrigated = true
+	b.Edges["e18"] = e
+	assert.False(t, b.IrrigationNetwork()["e18"])
+}
+
+func TestEdgesWithinChannels(t *testing.T) {
+	b := NewBoard()
+	b.AddPlot("p1", GreenBambooPlot, NoImprovement)
+	b.AddPlot("p2", YellowBambooPlot, NoImprovement)
+	b.AddPlot("p7", PinkBambooPlot, NoImprovement)
+	b.AddPlot("p8", GreenBambooPlot, NoImprovement)
+
+	assert.Equal(t, map[string]int{"e6": 1}, b.EdgesWithinChannels(1))
+	// e12 and e13 connect p7 to p1 and p2, and can be reached from e6
+	assert.Equal(t, map[string]int{"e6": 1, "e12": 2, "e13": 2}, b.EdgesWithinChannels(2))
+	// e15 (p2 to p8) and e14 (p7 to p8) need a third channel. edges next to future plots never carry water
+	within3 := b.EdgesWithinChannels(3)
+	assert.Equal(t, 3, within3["e15"])
+	assert.Equal(t, 3, within3["e14"])
+	assert.Equal(t, 5, len(within3))
+	assert.Empty(t, b.EdgesWithinChannels(0))
+}
+
 func TestBambooMechanics(t *testing.T) {
 	b := NewBoard()
 	b.AddPlot("p1", GreenBambooPlot, NoImprovement)
 	b.AddPlot("p2", GreenBambooPlot, EnclosureImprovement)
 	b.AddPlot("p3", GreenBambooPlot, FertilizerImprovement)
 	b.AddPlot("p7", GreenBambooPlot, NoImprovement)
-	// manually grow some bamboo, see that it works
-	b.PlotGrowBamboo("p3")
-	assert.Equal(t, 2, b.Plots["p3"].Bamboo)
-	b.PlotGrowBamboo("p2")
-	b.PlotGrowBamboo("p1")
+	// plots next to the pond grow their first bamboo as soon as they are placed
 	assert.Equal(t, 1, b.Plots["p1"].Bamboo)
 	assert.Equal(t, 1, b.Plots["p2"].Bamboo)
-	// move the gardener and check that he works properly
-	b.MoveGardener("p3")
-	b.MoveGardener("p2")
+	assert.Equal(t, 2, b.Plots["p3"].Bamboo) // fertilizer doubles the first growth too
+	assert.Equal(t, 0, b.Plots["p7"].Bamboo) // p7 is not irrigated
+	// manually grow some bamboo, see that it works
+	b.PlotGrowBamboo("p3")
 	assert.Equal(t, 4, b.Plots["p3"].Bamboo)
-	assert.Equal(t, 2, b.Plots["p2"].Bamboo)
-	assert.Equal(t, "p2", b.GardenerLocation)
+	b.PlotGrowBamboo("p7")
+	assert.Equal(t, 0, b.Plots["p7"].Bamboo)
+	// move the gardener and check that he works properly. each move also grows the adjacent irrigated green plots
+	assert.Equal(t, []string{"p2", "p1"}, b.MoveGardener("p2"))
+	assert.Equal(t, []string{"p2"}, b.MoveGardener("p3")) // p3 is already full
+	assert.Equal(t, 4, b.Plots["p3"].Bamboo)
+	assert.Equal(t, 3, b.Plots["p2"].Bamboo)
+	assert.Equal(t, 2, b.Plots["p1"].Bamboo)
+	assert.Equal(t, 0, b.Plots["p7"].Bamboo)
+	assert.Equal(t, "p3", b.GardenerLocation)
 	// move the panda around and check that he works properly
 	bb := b.MovePanda("p1")
 	assert.Equal(t, GreenBambooPlot, bb)
@@ -218,6 +266,67 @@ func TestBambooMechanics(t *testing.T) {
 	assert.Equal(t, 4, b.Plots["p2"].Bamboo) // gardener cannot make bamboo height exceed 4 on regular tile (but movement to that tile is a player option)
 }
 
+func TestFirstIrrigationGrowth(t *testing.T) {
+	b := NewBoard()
+	// next to the pond
+	b.AddPlot("p1", GreenBambooPlot, NoImprovement)
+	b.AddPlot("p2", YellowBambooPlot, NoImprovement)
+	assert.Equal(t, 1, b.Plots["p1"].Bamboo)
+	assert.Equal(t, 1, b.Plots["p2"].Bamboo)
+	// a plot that comes with a watershed
+	b.AddPlot("p7", PinkBambooPlot, WatershedImprovement)
+	assert.Equal(t, 1, b.Plots["p7"].Bamboo)
+	// irrigation channels. e6 runs between p1 and p2, which are already irrigated
+	b.AddPlot("p8", GreenBambooPlot, NoImprovement)
+	assert.Equal(t, 0, b.Plots["p8"].Bamboo)
+	b.EdgeAddIrrigation("e6")
+	assert.Equal(t, 1, b.Plots["p1"].Bamboo)
+	assert.Equal(t, 1, b.Plots["p2"].Bamboo)
+	b.EdgeAddIrrigation("e12")
+	assert.Equal(t, 0, b.Plots["p8"].Bamboo)
+	// e15 runs between p2 and p8
+	b.EdgeAddIrrigation("e15")
+	assert.Equal(t, 1, b.Plots["p8"].Bamboo)
+	// irrigating p8 again does not grow it again
+	b.EdgeAddIrrigation("e14")
+	assert.Equal(t, 1, b.Plots["p8"].Bamboo)
+}
+
+func TestFirstIrrigationGrowthFromWatershed(t *testing.T) {
+	b := NewBoard()
+	b.AddPlot("p1", GreenBambooPlot, NoImprovement)
+	b.AddPlot("p2", YellowBambooPlot, NoImprovement)
+	b.AddPlot("p7", PinkBambooPlot, NoImprovement)
+	assert.Equal(t, 0, b.Plots["p7"].Bamboo)
+	b.PlotAddImprovement("p7", WatershedImprovement)
+	assert.Equal(t, 1, b.Plots["p7"].Bamboo)
+	// other improvements don't irrigate
+	b.AddPlot("p8", GreenBambooPlot, NoImprovement)
+	b.PlotAddImprovement("p8", FertilizerImprovement)
+	assert.Equal(t, 0, b.Plots["p8"].Bamboo)
+}
+
+func TestGardenerGrowsSameColor(t *testing.T) {
+	b := NewBoard()
+	b.AddPlot("p1", GreenBambooPlot, NoImprovement)
+	b.AddPlot("p2", YellowBambooPlot, NoImprovement)
+	b.AddPlot("p3", GreenBambooPlot, FertilizerImprovement)
+	b.AddPlot("p4", GreenBambooPlot, EnclosureImprovement)
+
+	grown := b.MoveGardener("p3")
+
+	// p2 is the wrong color and p1 is not adjacent to p3
+	assert.Equal(t, []string{"p3", "p4"}, grown)
+	assert.Equal(t, 4, b.Plots["p3"].Bamboo)
+	assert.Equal(t, 2, b.Plots["p4"].Bamboo)
+	assert.Equal(t, 1, b.Plots["p1"].Bamboo)
+	assert.Equal(t, 1, b.Plots["p2"].Bamboo)
+
+	// the gardener can stand on the pond, but nothing grows there
+	assert.Equal(t, 0, len(b.MoveGardener("p0")))
+	assert.Equal(t, 0, b.Plots["p0"].Bamboo)
+}
+
 func TestImprovementTypeEqual(t *testing.T) {
 	cases := []struct {
 		a      ImprovementType
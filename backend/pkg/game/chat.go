@@ -0,0 +1,96 @@
+package game
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	MaxChatLength = 500 // characters in one message
+	chatBurst     = 5   // messages a person can send within chatWindow
+	chatWindow    = 10 * time.Second
+	// the most messages sent in one page of history
+	MaxChatPage = 50
+)
+
+// a message sent to the room. only Message and Gid come from the client, the server fills in the rest
+type ChatMessage struct {
+	From      string    `json:"from"`
+	Message   string    `json:"message"`
+	Gid       string    `json:"gid"`
+	Timestamp time.Time `json:"timestamp"`
+	Spectator bool      `json:"spectator"` // sent by a spectator rather than a player
+}
+
+// a request for the chat messages sent before the message at position Before. 0 asks for the latest messages
+type ChatHistoryRequest struct {
+	Gid    string `json:"gameId"`
+	Before int    `json:"before"`
+	Limit  int    `json:"limit"`
+}
+
+// one page of a game's chat, oldest message first. Start is the position of the first message, and the Before to ask for the page ahead of this one
+type ChatPage struct {
+	Gid      string        `json:"gameId"`
+	Messages []ChatMessage `json:"messages"`
+	Start    int           `json:"start"`
+	More     bool          `json:"more"` // there are older messages
+}
+
+const (
+	EmptyChatCode   ValidationCode = "EMPTY_MESSAGE"
+	ChatTooLongCode ValidationCode = "MESSAGE_TOO_LONG"
+	ChatTooFastCode ValidationCode = "SENDING_TOO_FAST"
+)
+
+// returns why the message can't be added to the log, or nil if it can. senders are limited to a few messages at a time,
+// counted from the log itself
+func ValidateChat(log []ChatMessage, msg ChatMessage) *ValidationError {
+	if strings.TrimSpace(msg.Message) == "" {
+		return &ValidationError{EmptyChatCode, "the message is empty"}
+	}
+	if utf8.RuneCountInString(msg.Message) > MaxChatLength {
+		return &ValidationError{ChatTooLongCode, fmt.Sprintf("messages can't be longer than %d characters", MaxChatLength)}
+	}
+	recent := 0
+	for i := len(log) - 1; i >= 0 && msg.Timestamp.Sub(log[i].Timestamp) < chatWindow; i-- {
+		if log[i].From == msg.From {
+			recent++
+		}
+	}
+	if recent >= chatBurst {
+		return &ValidationError{ChatTooFastCode, "slow down, you are sending messages too quickly"}
+	}
+	return nil
+}
+
+// the messages the person can read. when spectators chat separately, players don't see what spectators send
+func (l Lobby) VisibleChat(log []ChatMessage, pid string) []ChatMessage {
+	if !l.Settings.SeparateSpectatorChat || l.IsSpectator(pid) {
+		return log
+	}
+	return slices.DeleteFunc(slices.Clone(log), func(m ChatMessage) bool { return m.Spectator })
+}
+
+func (l Lobby) IsSpectator(pid string) bool {
+	return slices.Contains(l.Spectators, pid)
+}
+
+// the page of messages before position before. limit is capped at MaxChatPage
+func PageChat(log []ChatMessage, before, limit int) ChatPage {
+	if before <= 0 || before > len(log) {
+		before = len(log)
+	}
+	if limit <= 0 || limit > MaxChatPage {
+		limit = MaxChatPage
+	}
+	start := max(before-limit, 0)
+	return ChatPage{
+		Messages: slices.Clone(log[start:before]),
+		Start:    start,
+		More:     start > 0,
+	}
+}
@@ -0,0 +1,65 @@
+package game
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateChat(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	msg := func(from, text string, at time.Duration) ChatMessage {
+		return ChatMessage{From: from, Message: text, Timestamp: start.Add(at)}
+	}
+	assert.Nil(t, ValidateChat(nil, msg("a", "hello", 0)))
+	assert.Equal(t, EmptyChatCode, ValidateChat(nil, msg("a", "  ", 0)).Code)
+	assert.Equal(t, ChatTooLongCode, ValidateChat(nil, msg("a", strings.Repeat("x", MaxChatLength+1), 0)).Code)
+	// length is counted in characters, not bytes
+	assert.Nil(t, ValidateChat(nil, msg("a", strings.Repeat("竹", MaxChatLength), 0)))
+
+	log := make([]ChatMessage, 0)
+	for i := range chatBurst {
+		log = append(log, msg("a", "spam", time.Duration(i)*time.Second))
+	}
+	assert.Equal(t, ChatTooFastCode, ValidateChat(log, msg("a", "more", 5*time.Second)).Code)
+	assert.Nil(t, ValidateChat(log, msg("b", "someone else can talk", 5*time.Second)))
+	// the window moves on
+	assert.Nil(t, ValidateChat(log, msg("a", "later", chatWindow+time.Second)))
+}
+
+func TestPageChat(t *testing.T) {
+	log := make([]ChatMessage, 120)
+	for i := range log {
+		log[i].Message = strings.Repeat("x", i)
+	}
+
+	p := PageChat(log, 0, 0)
+	assert.Equal(t, 70, p.Start)
+	assert.Len(t, p.Messages, MaxChatPage)
+	assert.Equal(t, log[119], p.Messages[MaxChatPage-1])
+	assert.True(t, p.More)
+
+	p = PageChat(log, p.Start, 30)
+	assert.Equal(t, 40, p.Start)
+	assert.Equal(t, log[40:70], p.Messages)
+
+	p = PageChat(log, 10, 30)
+	assert.Equal(t, 0, p.Start)
+	assert.Equal(t, log[:10], p.Messages)
+	assert.False(t, p.More)
+
+	assert.Empty(t, PageChat(nil, 0, 0).Messages)
+}
+
+func TestVisibleChat(t *testing.T) {
+	l := Lobby{Players: []string{"a"}, Spectators: []string{"s"}}
+	log := []ChatMessage{{From: "a", Message: "hi"}, {From: "s", Message: "go a!", Spectator: true}}
+	assert.Equal(t, log, l.VisibleChat(log, "a"))
+
+	l.Settings.SeparateSpectatorChat = true
+	assert.Equal(t, log[:1], l.VisibleChat(log, "a"))
+	assert.Equal(t, log, l.VisibleChat(log, "s"))
+	assert.Len(t, log, 2)
+}
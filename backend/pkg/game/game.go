@@ -1,11 +1,19 @@
 package game
 
 import (
+	"bytes"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
-	"math/rand"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"reflect"
 	"slices"
+	"strings"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 type ClientSafe interface {
@@ -42,16 +50,36 @@ type GameState struct {
 	AvailableImprovements ImprovementReserve `json:"improvementReserve"`
 	// number of irrigations available
 	IrrigationReserve int `json:"irrigationReserve"`
+	// bamboo that is not growing on the board or in a player's reserve. eaten bamboo returns here when it is spent on an objective
+	BambooSupply BambooReserve `json:"bambooSupply"`
 	// undrawn objectives
 	ObjectiveDecks map[ObjectiveType][]Objective `json:"objectiveDecks"`
 	// messages sent by the server to players
 	GameLog []GameMessage `json:"gameLog"`
-	// messages sent by players to the room
-	ChatLog []ChatMessage `json:"chatLog"`
 	// playerID of player who won emperor
 	EmperorWinner string `json:"emperor"`
 	// keeps track of where in the game the turn in
 	TurnCounter TurnCounter `json:"turnCounter"`
+	// seeds every shuffle, die roll and prompt id. the same seed and the same responses will always play out the same game
+	Seed uint64 `json:"seed"`
+	// how many times randomness has been drawn from the seed
+	RandomDraws uint64 `json:"randomDraws"`
+	// every accepted player response, in order. the seed and the journal can rebuild the game
+	Journal Journal `json:"journal"`
+	// the lobby's settings, fixed when the game starts
+	Settings Settings `json:"settings"`
+}
+
+// relinks the board to the bamboo supply, since the link is not part of the board's json
+func (g *GameState) UnmarshalJSON(b []byte) error {
+	type gameState GameState
+	if err := json.Unmarshal(b, (*gameState)(g)); err != nil {
+		return err
+	}
+	if g.Board != nil {
+		g.Board.SetBambooSupply(g.BambooSupply)
+	}
+	return nil
 }
 
 func (g GameState) ClientSafe(recipient string) any {
@@ -62,10 +90,16 @@ func (g GameState) ClientSafe(recipient string) any {
 		IrrigationReserve:     g.IrrigationReserve,
 		EmperorWinner:         g.EmperorWinner,
 		TurnCounter:           g.TurnCounter,
+		Settings:              g.Settings,
+		BambooSupply:          g.BambooSupply,
 	}
+	t := now()
 	cp := make([]ClientPlayer, len(g.Players))
 	for i, p := range g.Players {
 		cp[i] = p.ClientSafe(recipient)
+		if g.Settings.TimeBanks() {
+			cp[i].TimeBank = int(math.Ceil(g.BankRemaining(p.ID, t).Seconds()))
+		}
 	}
 	c.Players = cp
 	oh := make(map[ObjectiveType]int)
@@ -88,6 +122,8 @@ type ClientGameState struct {
 	ObjectiveDeckHeights  map[ObjectiveType]int `json:"objectiveDeckHeights"`
 	EmperorWinner         string                `json:"emperor"`
 	TurnCounter           TurnCounter           `json:"turnCounter"`
+	Settings              Settings              `json:"settings"`
+	BambooSupply          BambooReserve         `json:"bambooSupply"`
 }
 
 type ClientTurn struct {
@@ -105,8 +141,9 @@ type Turn struct {
 	PlayerID         string       `json:"playerId"`
 	ActionsUsed      []ActionType `json:"actionsUsed"` // the actions the player has already taken
 	CurrentPrompt    Prompt       `json:"prompt"`
-	ContextSelection interface{}  `json:"-"` // for actions that require 2 choices, this is the first choice
+	ContextSelection interface{}  `json:"contextSelection"` // for actions that require 2 choices, this is the first choice
 	Weather          WeatherType  `json:"weather"`
+	Started          time.Time    `json:"started"` // when the turn began. the current player's time bank runs from here
 }
 
 func (t Turn) ClientSafe(recipient string) ClientTurn {
@@ -121,13 +158,6 @@ func (t Turn) ClientSafe(recipient string) ClientTurn {
 	return c
 }
 
-type ChatMessage struct {
-	From      string    `json:"from"`
-	Message   string    `json:"message"`
-	Gid       string    `json:"gid"`
-	Timestamp time.Time `json:"timestamp"`
-}
-
 type GameMessage struct {
 	Message   string    `json:"message"`
 	Timestamp time.Time `json:"timestamp"`
@@ -138,7 +168,8 @@ type TurnCounter struct {
 	Position int `json:"position"`
 }
 
-func NewGame() *GameState {
+// creates a new game with shuffled decks. Without a seed option, a random seed is chosen
+func NewGame(cfgs ...func(*GameState)) *GameState {
 	od := make(map[ObjectiveType][]Objective)
 	ir := make(map[ImprovementType]int)
 	pd := make([]DeckPlot, 0)
@@ -149,21 +180,6 @@ func NewGame() *GameState {
 	if err != nil {
 		panic(err)
 	}
-	// shuffle plots and objectives, 3 times each to get them mixed up good
-	for x := 0; x < 3; x++ {
-		rand.Shuffle(len(pd), func(i, j int) {
-			pd[i], pd[j] = pd[j], pd[i]
-		})
-		rand.Shuffle(len(od[PlotObjectiveType]), func(i, j int) {
-			od[PlotObjectiveType][i], od[PlotObjectiveType][j] = od[PlotObjectiveType][j], od[PlotObjectiveType][i]
-		})
-		rand.Shuffle(len(od[PandaObjectiveType]), func(i, j int) {
-			od[PandaObjectiveType][i], od[PandaObjectiveType][j] = od[PandaObjectiveType][j], od[PandaObjectiveType][i]
-		})
-		rand.Shuffle(len(od[GardenerObjectiveType]), func(i, j int) {
-			od[GardenerObjectiveType][i], od[GardenerObjectiveType][j] = od[GardenerObjectiveType][j], od[GardenerObjectiveType][i]
-		})
-	}
 	g := &GameState{
 		Board:                 NewBoard(),
 		IrrigationReserve:     20,
@@ -171,35 +187,94 @@ func NewGame() *GameState {
 		AvailableImprovements: ir,
 		PlotDeck:              pd,
 		GameLog:               make([]GameMessage, 0),
-		ChatLog:               make([]ChatMessage, 0),
 		CurrentTurn: Turn{
 			Weather:     NoWeather,
 			ActionsUsed: make([]ActionType, 0),
+			// the first call to GameFlow advances the game to the first player's turn
+			CurrentPrompt: Prompt{Action: NextPlayerTurn},
 		},
 		TurnCounter: TurnCounter{
 			Round:    0,
 			Position: -1,
 		},
+		Seed:     rand.Uint64(),
+		Settings: DefaultSettings(),
+	}
+	for _, fn := range cfgs {
+		fn(g)
+	}
+	g.BambooSupply = g.Settings.bambooSupply()
+	g.Board.SetBambooSupply(g.BambooSupply)
+	// shuffle plots and objectives, 3 times each to get them mixed up good
+	r := g.random()
+	for x := 0; x < 3; x++ {
+		r.Shuffle(len(pd), func(i, j int) {
+			pd[i], pd[j] = pd[j], pd[i]
+		})
+		r.Shuffle(len(od[PlotObjectiveType]), func(i, j int) {
+			od[PlotObjectiveType][i], od[PlotObjectiveType][j] = od[PlotObjectiveType][j], od[PlotObjectiveType][i]
+		})
+		r.Shuffle(len(od[PandaObjectiveType]), func(i, j int) {
+			od[PandaObjectiveType][i], od[PandaObjectiveType][j] = od[PandaObjectiveType][j], od[PandaObjectiveType][i]
+		})
+		r.Shuffle(len(od[GardenerObjectiveType]), func(i, j int) {
+			od[GardenerObjectiveType][i], od[GardenerObjectiveType][j] = od[GardenerObjectiveType][j], od[GardenerObjectiveType][i]
+		})
 	}
 	return g
 }
 
+// use with NewGame or StartGame to make a reproducible game
+func WithSeed(seed uint64) func(*GameState) {
+	return func(g *GameState) {
+		g.Seed = seed
+	}
+}
+
 func (g *GameState) AddPlayers(ps []Player) {
 	// shuffle player order
-	rand.Shuffle(len(ps), func(i, j int) {
-		ps[1], ps[j] = ps[j], ps[i]
+	g.random().Shuffle(len(ps), func(i, j int) {
+		ps[i], ps[j] = ps[j], ps[i]
 	})
+	// players usually arrive with only an id and a name
+	for i := range ps {
+		if ps[i].Bamboo == nil {
+			ps[i].Bamboo = make(BambooReserve)
+		}
+		if ps[i].Improvements == nil {
+			ps[i].Improvements = make(ImprovementReserve)
+		}
+	}
 	g.Players = ps
 	g.CurrentTurn.PlayerID = ps[0].ID
 }
 
+// the opening deal. In turn order, every player gets one plot, one gardener and one panda objective
+func (g *GameState) DealObjectives() {
+	for i := range g.Players {
+		for _, ot := range []ObjectiveType{PlotObjectiveType, GardenerObjectiveType, PandaObjectiveType} {
+			if len(g.ObjectiveDecks[ot]) == 0 {
+				continue
+			}
+			g.Players[i].Objectives = append(g.Players[i].Objectives, g.DrawObjective(ot))
+		}
+	}
+}
+
 func (g *GameState) NextTurn() {
-	order := (g.TurnCounter.Position + 1) % len(g.Players)
-	if order == 0 {
-		g.TurnCounter.Round++
+	var player Player
+	for {
+		order := (g.TurnCounter.Position + 1) % len(g.Players)
+		if order == 0 {
+			g.TurnCounter.Round++
+		}
+		g.TurnCounter.Position = order
+		player = g.Players[order]
+		// forfeited players are skipped, except the emperor winner whose turn still ends the game
+		if !player.Forfeited || player.ID == g.EmperorWinner || g.activePlayers() == 0 {
+			break
+		}
 	}
-	g.TurnCounter.Position = order
-	player := g.Players[order]
 	g.CurrentTurn = Turn{
 		PlayerID:    player.ID,
 		Weather:     NoWeather,
@@ -239,6 +314,7 @@ func (g *GameState) AvailableObjectiveTypes() []ObjectiveType {
 			s = append(s, k)
 		}
 	}
+	slices.Sort(s)
 	return s
 }
 
@@ -250,20 +326,76 @@ func (g *GameState) DrawObjective(ot ObjectiveType) Objective {
 
 func (g GameState) GetCurrentPlayer() *Player {
 	pid := g.CurrentTurn.PlayerID
-	for _, p := range g.Players {
-		if p.ID == pid {
-			return &p
+	for i := range g.Players {
+		if g.Players[i].ID == pid {
+			return &g.Players[i]
 		}
 	}
 	return nil
 }
 
+func (g *GameState) GetPlayer(pid string) *Player {
+	for i := range g.Players {
+		if g.Players[i].ID == pid {
+			return &g.Players[i]
+		}
+	}
+	return nil
+}
+
+// the number of players who have not forfeited
+func (g *GameState) activePlayers() int {
+	n := 0
+	for _, p := range g.Players {
+		if !p.Forfeited {
+			n++
+		}
+	}
+	return n
+}
+
+// true once forfeits have left fewer than 2 players in the game
+func (g *GameState) lastPlayerStanding() bool {
+	return g.activePlayers() < 2 && g.activePlayers() < len(g.Players)
+}
+
+// how much of a player's time bank is left at time t. The bank only runs during the player's own turn
+func (g *GameState) BankRemaining(pid string, t time.Time) time.Duration {
+	p := g.GetPlayer(pid)
+	if p == nil {
+		return 0
+	}
+	bank := p.TimeBank
+	if g.CurrentTurn.PlayerID == pid && !g.CurrentTurn.Started.IsZero() {
+		bank -= t.Sub(g.CurrentTurn.Started)
+	}
+	return max(bank, 0)
+}
+
+// charge the current player for the time their turn took
+func (g *GameState) chargeTimeBank(t time.Time) {
+	if !g.Settings.TimeBanks() || g.CurrentTurn.Started.IsZero() {
+		return
+	}
+	if p := g.GetCurrentPlayer(); p != nil {
+		p.TimeBank = g.BankRemaining(p.ID, t)
+	}
+}
+
+// start the clock on the current player's turn and add their increment
+func (g *GameState) startTurn(t time.Time) {
+	g.CurrentTurn.Started = t
+	if g.Settings.TimeBanks() {
+		g.GetCurrentPlayer().TimeBank += g.Settings.increment()
+	}
+}
+
 func (g *GameState) NextChooseActionPrompt() Prompt {
 	p := Prompt{
 		Action:     ChooseAction,
 		SelectType: ActionSelectType,
 		Time:       60,
-		Pid:        NewPromptID(),
+		Pid:        g.nextPromptID(),
 	}
 
 	currentPlayer := g.GetCurrentPlayer()
@@ -279,10 +411,11 @@ func (g *GameState) NextChooseActionPrompt() Prompt {
 		couldEndTurn = true
 	}
 
-	if currentPlayer.Irrigations > 0 {
+	// free actions are only offered when there is somewhere to use them
+	if currentPlayer.Irrigations > 0 && len(g.Board.AllIrrigatableEdges()) > 0 {
 		options = append(options, PlaceIrrigation)
 	}
-	if !currentPlayer.Improvements.IsEmpty() {
+	if !currentPlayer.Improvements.IsEmpty() && len(g.Board.AllImprovablePlots()) > 0 {
 		options = append(options, PlaceImprovement)
 	}
 	// add end turn if condition is met
@@ -306,38 +439,105 @@ func (g *GameState) availableRegularActions() []ActionType {
 		return []ActionType{}
 	}
 	regularActions := []ActionType{PlacePlot, MovePanda, MoveGardener, CollectIrrigation, DrawObjective}
+	remove := func(a ActionType) {
+		regularActions = slices.DeleteFunc(regularActions, func(ra ActionType) bool {
+			return ra == a
+		})
+	}
 	if weather != WindWeather {
 		for _, a := range used {
-			i := slices.Index(regularActions, a)
-			regularActions = slices.Delete(regularActions, i, i+1)
+			remove(a)
 		}
 	}
 	if g.IrrigationReserve == 0 {
-		i := slices.Index(regularActions, CollectIrrigation)
-		regularActions = slices.Delete(regularActions, i, i+1)
+		remove(CollectIrrigation)
 	}
 	if len(g.PlotDeck) == 0 {
-		i := slices.Index(regularActions, PlacePlot)
-		regularActions = slices.Delete(regularActions, i, i+1)
+		remove(PlacePlot)
 	}
-	if len(g.AvailableObjectiveTypes()) == 0 {
-		i := slices.Index(regularActions, DrawObjective)
-		regularActions = slices.Delete(regularActions, i, i+1)
+	if len(g.AvailableObjectiveTypes()) == 0 || len(g.GetCurrentPlayer().Objectives) >= g.Settings.handLimit() {
+		remove(DrawObjective)
+	}
+	// the panda and gardener can't be moved if there is nowhere for them to go
+	if len(g.Board.LegalMovesFromPlot(g.Board.PandaLocation)) == 0 {
+		remove(MovePanda)
+	}
+	if len(g.Board.LegalMovesFromPlot(g.Board.GardenerLocation)) == 0 {
+		remove(MoveGardener)
 	}
 	return regularActions
 }
 
-func (g *GameState) ValidatePlayerAction(action PromptResponse) bool {
-	if g.CurrentTurn.CurrentPrompt.Action != action.Action {
-		return false
+// every response the current prompt would accept, with the selection converted to its type and ready to submit.
+// a prompt without options takes a single response with no selection. nothing is legal once the game has ended
+func (g *GameState) LegalResponses() []PromptResponse {
+	p := g.CurrentTurn.CurrentPrompt
+	if p.Action == EndGame {
+		return []PromptResponse{}
 	}
-	if g.CurrentTurn.CurrentPrompt.Pid != action.Pid {
-		return false
+	base := PromptResponse{Action: p.Action, Pid: p.Pid, Gid: p.Gid}
+	if len(p.SelectFrom) == 0 {
+		return []PromptResponse{base}
 	}
-	if len(g.CurrentTurn.CurrentPrompt.SelectFrom) > 0 && !slices.Contains(g.CurrentTurn.CurrentPrompt.SelectFrom, action.Selection) {
-		return false
+	responses := make([]PromptResponse, len(p.SelectFrom))
+	for i, option := range p.SelectFrom {
+		responses[i] = base
+		responses[i].Selection = p.selection(option)
+	}
+	return responses
+}
+
+// converts a selection for the prompt to its type. the legal responses and validation both convert with this, so they always agree.
+// a prompt without options takes no selection, so there is nothing to convert
+func (p Prompt) selection(s any) any {
+	if len(p.SelectFrom) == 0 {
+		return s
+	}
+	return GetSelection(p.Action, s)
+}
+
+// why a response was rejected, in a form clients can act on
+type ValidationCode string
+
+const (
+	WrongPromptCode      ValidationCode = "WRONG_PROMPT"      // the response is for a different kind of prompt than the one waiting
+	StalePromptCode      ValidationCode = "STALE_PROMPT"      // the response answers a prompt that is no longer waiting
+	InvalidSelectionCode ValidationCode = "INVALID_SELECTION" // the selection is not one of the prompt's options
+	NotYourTurnCode      ValidationCode = "NOT_YOUR_TURN"     // another player is taking their turn
+	NotPlayingCode       ValidationCode = "NOT_PLAYING"       // the sender isn't seated in the game, or has forfeited
+	NotStartedCode       ValidationCode = "NOT_STARTED"       // the game is still in its lobby
+	GameOverCode         ValidationCode = "GAME_OVER"         // the game has ended
+)
+
+type ValidationError struct {
+	Code    ValidationCode `json:"code"`
+	Message string         `json:"message"`
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// returns nil when the response is one of the legal responses, and why it isn't otherwise.
+// the game id is not checked, it only routes the response to this game
+func (g *GameState) ValidatePlayerAction(action PromptResponse) *ValidationError {
+	p := g.CurrentTurn.CurrentPrompt
+	switch {
+	case p.Action == EndGame:
+		return &ValidationError{GameOverCode, "the game is over"}
+	case action.Pid != p.Pid:
+		return &ValidationError{StalePromptCode, "that prompt has already been answered"}
+	case action.Action != p.Action:
+		return &ValidationError{WrongPromptCode, fmt.Sprintf("expected a response to %s, not %s", p.Action, action.Action)}
+	}
+	// compare converted selections, the response may have come from a client as raw json
+	selection := p.selection(action.Selection)
+	if !slices.ContainsFunc(g.LegalResponses(), func(r PromptResponse) bool {
+		return reflect.DeepEqual(r.Selection, selection)
+	}) {
+		return &ValidationError{InvalidSelectionCode, fmt.Sprintf("%v is not one of the options", action.Selection)}
 	}
-	return true
+	return nil
 }
 
 // process a player's choice and return the next prompt
@@ -365,7 +565,10 @@ func (g *GameState) ProcessPlayerAction(action PromptResponse) Prompt {
 		return g.NextChooseActionPrompt()
 	case ChooseGardenerDestination:
 		// move gardner
-		g.Board.MoveGardener(action.Selection.(string))
+		grown := g.Board.MoveGardener(action.Selection.(string))
+		if len(grown) > 0 {
+			g.logf("%s's gardener grew bamboo on %s", g.GetCurrentPlayer().Name, strings.Join(grown, ", "))
+		}
 		return g.NextChooseActionPrompt()
 	case ChooseImprovementDestination:
 		// place the improvement the player chose earlier on the plot they just chose
@@ -379,7 +582,10 @@ func (g *GameState) ProcessPlayerAction(action PromptResponse) Prompt {
 		return g.NextChooseActionPrompt()
 	case ChooseIrrigationDestination:
 		//
-		g.Board.EdgeAddIrrigation(action.Selection.(string))
+		if err := g.Board.EdgeAddIrrigation(action.Selection.(string)); err != nil {
+			// the channel was not placed, so the player keeps it
+			return g.NextChooseActionPrompt()
+		}
 		p := g.GetCurrentPlayer()
 		p.Irrigations--
 		return g.NextChooseActionPrompt()
@@ -399,7 +605,7 @@ func (g *GameState) ProcessPlayerAction(action PromptResponse) Prompt {
 			SelectType: PlotIDSelectType,
 			SelectFrom: ConvertToInterfaceSlice(options),
 			Time:       45,
-			Pid:        NewPromptID(),
+			Pid:        g.nextPromptID(),
 		}
 	case ChooseImprovementToUse:
 		//
@@ -413,7 +619,7 @@ func (g *GameState) ProcessPlayerAction(action PromptResponse) Prompt {
 			SelectType: PlotIDSelectType,
 			SelectFrom: ConvertToInterfaceSlice(options),
 			Time:       45,
-			Pid:        NewPromptID(),
+			Pid:        g.nextPromptID(),
 		}
 	case ChooseImprovementToStash:
 		//
@@ -431,7 +637,7 @@ func (g *GameState) ProcessPlayerAction(action PromptResponse) Prompt {
 		return g.NextChooseActionPrompt()
 	case RollDie:
 		//
-		w := RollWeatherDie(!g.AvailableImprovements.IsEmpty())
+		w := RollWeatherDie(g.random(), !g.AvailableImprovements.IsEmpty())
 		if w == ChoiceWeather {
 			options := []WeatherType{
 				SunWeather,
@@ -446,7 +652,7 @@ func (g *GameState) ProcessPlayerAction(action PromptResponse) Prompt {
 				Action:     ChooseWeather,
 				SelectType: WeatherSelectType,
 				SelectFrom: ConvertToInterfaceSlice(options),
-				Pid:        NewPromptID(),
+				Pid:        g.nextPromptID(),
 				Time:       30,
 			}
 		}
@@ -455,12 +661,16 @@ func (g *GameState) ProcessPlayerAction(action PromptResponse) Prompt {
 		if w == RainWeather {
 			// prompt for growth
 			options := g.Board.AllIrrigatedPlots()
+			if len(options) == 0 {
+				// nothing can grow, so the rain is wasted
+				return g.NextChooseActionPrompt()
+			}
 			return Prompt{
 				Action:     ChooseGrowth,
 				SelectType: PlotIDSelectType,
 				SelectFrom: ConvertToInterfaceSlice(options),
 				Time:       45,
-				Pid:        NewPromptID(),
+				Pid:        g.nextPromptID(),
 			}
 		} else if w == BoltWeather {
 			// prompt for panda move
@@ -470,7 +680,7 @@ func (g *GameState) ProcessPlayerAction(action PromptResponse) Prompt {
 				SelectType: PlotIDSelectType,
 				SelectFrom: ConvertToInterfaceSlice(options),
 				Time:       45,
-				Pid:        NewPromptID(),
+				Pid:        g.nextPromptID(),
 			}
 		} else if w == CloudWeather {
 			// prompt for improvement selection
@@ -480,7 +690,7 @@ func (g *GameState) ProcessPlayerAction(action PromptResponse) Prompt {
 				SelectType: ImprovementSelectType,
 				SelectFrom: ConvertToInterfaceSlice(options),
 				Time:       30,
-				Pid:        NewPromptID(),
+				Pid:        g.nextPromptID(),
 			}
 		} else {
 			// sun and wind proceed like normal
@@ -501,7 +711,7 @@ func (g *GameState) PromptForAction(at ActionType) Prompt {
 			SelectType: PlotSelectType,
 			SelectFrom: ConvertToInterfaceSlice(options),
 			Time:       45,
-			Pid:        NewPromptID(),
+			Pid:        g.nextPromptID(),
 		}
 	case CollectIrrigation:
 		//
@@ -518,7 +728,7 @@ func (g *GameState) PromptForAction(at ActionType) Prompt {
 			SelectType: PlotIDSelectType,
 			SelectFrom: ConvertToInterfaceSlice(options),
 			Time:       45,
-			Pid:        NewPromptID(),
+			Pid:        g.nextPromptID(),
 		}
 	case MoveGardener:
 		//
@@ -529,7 +739,7 @@ func (g *GameState) PromptForAction(at ActionType) Prompt {
 			SelectType: PlotIDSelectType,
 			SelectFrom: ConvertToInterfaceSlice(options),
 			Time:       45,
-			Pid:        NewPromptID(),
+			Pid:        g.nextPromptID(),
 		}
 	case DrawObjective:
 		//
@@ -540,7 +750,7 @@ func (g *GameState) PromptForAction(at ActionType) Prompt {
 			SelectType: ObjectiveSelectType,
 			SelectFrom: ConvertToInterfaceSlice(options),
 			Time:       45,
-			Pid:        NewPromptID(),
+			Pid:        g.nextPromptID(),
 		}
 	case PlaceIrrigation:
 		//
@@ -550,7 +760,7 @@ func (g *GameState) PromptForAction(at ActionType) Prompt {
 			SelectType: EdgeIDSelectType,
 			SelectFrom: ConvertToInterfaceSlice(options),
 			Time:       45,
-			Pid:        NewPromptID(),
+			Pid:        g.nextPromptID(),
 		}
 	case PlaceImprovement:
 		//
@@ -561,7 +771,7 @@ func (g *GameState) PromptForAction(at ActionType) Prompt {
 			SelectType: ImprovementSelectType,
 			SelectFrom: ConvertToInterfaceSlice(options),
 			Time:       30,
-			Pid:        NewPromptID(),
+			Pid:        g.nextPromptID(),
 		}
 	case EndTurn:
 		fallthrough
@@ -570,11 +780,16 @@ func (g *GameState) PromptForAction(at ActionType) Prompt {
 	}
 }
 
+// completes the current player's objectives in the order they were drawn.
+// Panda objectives spend their bamboo, so objectives later in the hand only see the bamboo that is left
 func (g *GameState) CompleteObjectives() {
 	p := g.GetCurrentPlayer()
 	incomplete := make([]Objective, 0)
 	for _, o := range p.Objectives {
 		if o.IsComplete(*p, *g.Board) {
+			if po, ok := o.ObjectiveChecker.(PandaObjective); ok {
+				g.spendBamboo(p, po)
+			}
 			p.CompleteObjectives = append(p.CompleteObjectives, o)
 		} else {
 			incomplete = append(incomplete, o)
@@ -586,6 +801,18 @@ func (g *GameState) CompleteObjectives() {
 	p.Objectives = incomplete
 }
 
+// moves the bamboo a panda objective required from the player's reserve back to the supply
+func (g *GameState) spendBamboo(p *Player, o PandaObjective) {
+	cost := BambooReserve{GreenBambooPlot: o.GreenCount, YellowBambooPlot: o.YellowCount, PinkBambooPlot: o.PinkCount}
+	for pt, n := range cost {
+		if n == 0 {
+			continue
+		}
+		p.Bamboo[pt] -= n
+		g.BambooSupply[pt] += n
+	}
+}
+
 func (g *GameState) awardEmperorCard(p *Player) bool {
 	if g.EmperorWinner != "" {
 		return false
@@ -599,17 +826,50 @@ func (g *GameState) awardEmperorCard(p *Player) bool {
 	return false
 }
 
-var roll func(int) int = rand.Intn
+// adds a message to the game log, stamped with the time of the response being processed so a replay logs the same thing
+func (g *GameState) logf(format string, a ...any) {
+	t := now()
+	if n := len(g.Journal.Entries); n > 0 {
+		t = g.Journal.Entries[n-1].Timestamp
+	}
+	g.GameLog = append(g.GameLog, GameMessage{
+		Message:   fmt.Sprintf(format, a...),
+		Timestamp: t,
+	})
+}
+
+// gives a random source for a single use. Each call draws from a new stream of the game's seed,
+// so the sequence of random outcomes survives the game state being stored and loaded
+func (g *GameState) random() *rand.Rand {
+	defer func() {
+		g.RandomDraws++
+	}()
+	return rand.New(rand.NewPCG(g.Seed, g.RandomDraws))
+}
+
+// prompt ids are drawn from the game's seed so that a replayed game issues the same ids
+func (g *GameState) nextPromptID() string {
+	r := g.random()
+	var b [16]byte
+	binary.LittleEndian.PutUint64(b[:8], r.Uint64())
+	binary.LittleEndian.PutUint64(b[8:], r.Uint64())
+	id, _ := uuid.NewRandomFromReader(bytes.NewReader(b[:]))
+	return id.String()
+}
+
+var roll func(*rand.Rand, int) int = func(r *rand.Rand, n int) int {
+	return r.IntN(n)
+}
 
 // roll the weather die. The outcome depends on how many improvements are available.
-func RollWeatherDie(improvements bool) WeatherType {
+func RollWeatherDie(r *rand.Rand, improvements bool) WeatherType {
 	var w [6]WeatherType
 	if improvements {
 		w = [6]WeatherType{SunWeather, RainWeather, WindWeather, BoltWeather, CloudWeather, ChoiceWeather}
 	} else {
 		w = [6]WeatherType{SunWeather, RainWeather, WindWeather, BoltWeather, ChoiceWeather, ChoiceWeather}
 	}
-	r := roll(6)
+	i := roll(r, 6)
 
-	return w[r]
+	return w[i]
 }
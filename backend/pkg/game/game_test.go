@@ -3,7 +3,9 @@ package game
 import (
 	"bytes"
 	"encoding/json"
+	"math/rand/v2"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -19,6 +21,11 @@ func TestNewGame(t *testing.T) {
 	assert.Equal(t, 15, len(g.ObjectiveDecks[PlotObjectiveType]))
 	assert.Equal(t, 15, len(g.ObjectiveDecks[GardenerObjectiveType]))
 	assert.Equal(t, 15, len(g.ObjectiveDecks[PandaObjectiveType]))
+	yellow := 0
+	for _, o := range g.ObjectiveDecks[PandaObjectiveType] {
+		yellow += o.ObjectiveChecker.(PandaObjective).YellowCount
+	}
+	assert.Greater(t, yellow, 0)
 }
 
 func TestMarshalGame(t *testing.T) {
@@ -104,12 +111,13 @@ func TestValidatePlayerAction(t *testing.T) {
 	cases := []struct {
 		Name       string
 		PR         PromptResponse
-		ExpectPass bool
+		ExpectCode ValidationCode // empty when the response should pass
 	}{
-		{"Valid", PromptResponse{Action: ChooseAction, Selection: "MovePanda", Pid: pid}, true},
-		{"Invalid Action", PromptResponse{Action: ChooseGrowth, Selection: "MovePanda", Pid: pid}, false},
-		{"Invalid PID", PromptResponse{Action: ChooseAction, Selection: "MovePanda", Pid: "not pid"}, false},
-		{"Invalid Selection", PromptResponse{Action: ChooseAction, Selection: "DrawObjective", Pid: pid}, false},
+		{"Valid", PromptResponse{Action: ChooseAction, Selection: "MovePanda", Pid: pid}, ""},
+		{"Invalid Action", PromptResponse{Action: ChooseGrowth, Selection: "MovePanda", Pid: pid}, WrongPromptCode},
+		{"Invalid PID", PromptResponse{Action: ChooseAction, Selection: "MovePanda", Pid: "not pid"}, StalePromptCode},
+		{"Invalid Selection", PromptResponse{Action: ChooseAction, Selection: "DrawObjective", Pid: pid}, InvalidSelectionCode},
+		{"Selection Of The Wrong Shape", PromptResponse{Action: ChooseAction, Selection: map[string]any{"MovePanda": true}, Pid: pid}, InvalidSelectionCode},
 	}
 
 	for _, tc := range cases {
@@ -126,17 +134,121 @@ func TestValidatePlayerAction(t *testing.T) {
 				Pid: pid,
 			}
 
-			result := g.ValidatePlayerAction(tc.PR)
-			assert.Equal(tt, tc.ExpectPass, result)
+			err := g.ValidatePlayerAction(tc.PR)
+			if tc.ExpectCode == "" {
+				assert.Nil(tt, err)
+			} else if assert.NotNil(tt, err) {
+				assert.Equal(tt, tc.ExpectCode, err.Code)
+				assert.NotEmpty(tt, err.Message)
+			}
 		})
 	}
 
 }
 
+func TestLegalResponses(t *testing.T) {
+	g := NewGame()
+	g.CurrentTurn.CurrentPrompt = Prompt{
+		Action:     ChoosePlot,
+		SelectType: PlotSelectType,
+		// a stored game's deck plots come back as maps
+		SelectFrom: []any{
+			map[string]any{"type": "GREEN_BAMBOO", "improvement": "NONE"},
+			map[string]any{"type": "PINK_BAMBOO", "improvement": "WATERSHED"},
+		},
+		Pid: "prompt",
+		Gid: "game",
+	}
+	responses := g.LegalResponses()
+	assert.Equal(t, []PromptResponse{
+		{Action: ChoosePlot, Selection: DeckPlot{Type: GreenBambooPlot, Improvement: NoImprovement}, Pid: "prompt", Gid: "game"},
+		{Action: ChoosePlot, Selection: DeckPlot{Type: PinkBambooPlot, Improvement: WatershedImprovement}, Pid: "prompt", Gid: "game"},
+	}, responses)
+	for _, r := range responses {
+		assert.Nil(t, g.ValidatePlayerAction(r))
+	}
+	assert.Equal(t, InvalidSelectionCode, g.ValidatePlayerAction(PromptResponse{Action: ChoosePlot, Selection: DeckPlot{Type: YellowBambooPlot}, Pid: "prompt"}).Code)
+
+	// a prompt without options takes one response without a selection
+	g.CurrentTurn.CurrentPrompt = Prompt{Action: NextPlayerTurn}
+	assert.Equal(t, []PromptResponse{{Action: NextPlayerTurn}}, g.LegalResponses())
+
+	g.CurrentTurn.CurrentPrompt = Prompt{Action: EndGame}
+	assert.Empty(t, g.LegalResponses())
+	assert.Equal(t, GameOverCode, g.ValidatePlayerAction(PromptResponse{Action: EndGame}).Code)
+}
+
+func TestLegalResponsesForEveryPrompt(t *testing.T) {
+	// an option of each prompt type, as it comes back from storage
+	options := map[PromptType]any{
+		RollDie:                      string(RollDie),
+		ChooseWeather:                string(SunWeather),
+		ChooseImprovementToUse:       string(WatershedImprovement),
+		ChooseImprovementToStash:     string(FertilizerImprovement),
+		ChooseGrowth:                 "a",
+		ChoosePandaDestination:       "a",
+		ChooseAction:                 string(MovePanda),
+		ChoosePlot:                   map[string]any{"type": "GREEN_BAMBOO", "improvement": "NONE"},
+		ChoosePlotDestination:        "a",
+		ChooseGardenerDestination:    "a",
+		ChooseObjectiveType:          string(PandaObjectiveType),
+		ChooseIrrigationDestination:  "e",
+		ChooseImprovementDestination: "a",
+		NextPlayerTurn:               nil,
+	}
+	for action, option := range options {
+		for _, selectFrom := range [][]any{{option}, {}} {
+			if option == nil && len(selectFrom) > 0 {
+				continue
+			}
+			g := StartGame([]Player{{ID: "a"}, {ID: "b"}}, WithSeed(1))
+			GameFlow(g, PromptResponse{Action: NextPlayerTurn})
+			g.CurrentTurn.CurrentPrompt = Prompt{Action: action, SelectFrom: selectFrom, Pid: "prompt"}
+			legal := g.LegalResponses()
+			assert.Len(t, legal, max(len(selectFrom), 1))
+			for _, r := range legal {
+				assert.Nil(t, g.ValidatePlayerAction(r), "%s with %d options rejected %v", action, len(selectFrom), r.Selection)
+			}
+			if len(selectFrom) == 0 {
+				// a prompt without options is answered without a selection, and the game moves on
+				assert.NotNil(t, g.ValidatePlayerAction(PromptResponse{Action: action, Selection: "x", Pid: "prompt"}))
+				entries := len(g.Journal.Entries)
+				assert.NotPanics(t, func() { GameFlow(g, legal[0]) }, "%s without options", action)
+				assert.Equal(t, entries+1, len(g.Journal.Entries))
+			}
+		}
+	}
+}
+
+func TestLegalResponsesAreAccepted(t *testing.T) {
+	// play whole games choosing from the legal responses. every one of them must be accepted
+	for seed := range uint64(5) {
+		g := StartGame([]Player{{ID: "a"}, {ID: "b"}, {ID: "c"}}, WithSeed(seed))
+		prompt := GameFlow(g, PromptResponse{Action: NextPlayerTurn})
+		for turns := 0; prompt.Action != EndGame; turns++ {
+			if !assert.Less(t, turns, 2000, "seed %d never ended", seed) {
+				break
+			}
+			responses := g.LegalResponses()
+			for _, r := range responses {
+				assert.Nil(t, g.ValidatePlayerAction(r), "seed %d rejected %v", seed, r)
+			}
+			before := len(g.Journal.Entries)
+			prompt = GameFlow(g, responses[int(g.RandomDraws)%len(responses)])
+			assert.Equal(t, before+1, len(g.Journal.Entries))
+		}
+	}
+}
+
 func TestNextChooseAction(t *testing.T) {
 	g := NewGame()
 	g.AddPlayers([]Player{{ID: "dummy", Improvements: make(ImprovementReserve)}})
 	g.NextTurn()
+	// give the panda, gardener, irrigation and improvements somewhere to go
+	g.Board.AddPlot("p1", GreenBambooPlot, NoImprovement)
+	g.Board.AddPlot("p2", YellowBambooPlot, NoImprovement)
+	// p7 is not irrigated, so it stays bare and can be improved
+	g.Board.AddPlot("p7", PinkBambooPlot, NoImprovement)
 	// player has no resources and has used no actions, so should have 5 options
 	prompt := g.NextChooseActionPrompt()
 	assert.Equal(t, 5, len(prompt.SelectFrom))
@@ -195,6 +307,152 @@ func TestNextChooseAction(t *testing.T) {
 	assert.NotContains(t, prompt.SelectFrom, DrawObjective)
 }
 
+func TestNextChooseActionWithoutTargets(t *testing.T) {
+	g := NewGame()
+	g.AddPlayers([]Player{{ID: "dummy", Irrigations: 1, Improvements: ImprovementReserve{WatershedImprovement: 1}}})
+	g.NextTurn()
+	// an empty board has nowhere to move the panda or gardener, and nowhere to place irrigation or improvements
+	prompt := g.NextChooseActionPrompt()
+	assert.Equal(t, 3, len(prompt.SelectFrom))
+	assert.NotContains(t, prompt.SelectFrom, MovePanda)
+	assert.NotContains(t, prompt.SelectFrom, MoveGardener)
+	assert.NotContains(t, prompt.SelectFrom, PlaceIrrigation)
+	assert.NotContains(t, prompt.SelectFrom, PlaceImprovement)
+}
+
+func TestOpeningDeal(t *testing.T) {
+	g := StartGame([]Player{{ID: "a"}, {ID: "b"}})
+	for _, p := range g.Players {
+		assert.Equal(t, 3, len(p.Objectives))
+		assert.Equal(t, PlotObjectiveType, p.Objectives[0].Type())
+		assert.Equal(t, GardenerObjectiveType, p.Objectives[1].Type())
+		assert.Equal(t, PandaObjectiveType, p.Objectives[2].Type())
+	}
+	assert.Equal(t, 13, len(g.ObjectiveDecks[PlotObjectiveType]))
+	assert.Equal(t, 13, len(g.ObjectiveDecks[GardenerObjectiveType]))
+	assert.Equal(t, 13, len(g.ObjectiveDecks[PandaObjectiveType]))
+}
+
+func TestObjectiveHandLimit(t *testing.T) {
+	g := NewGame()
+	g.AddPlayers([]Player{{ID: "dummy", Improvements: make(ImprovementReserve)}})
+	g.NextTurn()
+	for i := 0; i < 4; i++ {
+		g.Players[0].Objectives = append(g.Players[0].Objectives, g.DrawObjective(PandaObjectiveType))
+	}
+	assert.Contains(t, g.availableRegularActions(), DrawObjective)
+	g.Players[0].Objectives = append(g.Players[0].Objectives, g.DrawObjective(PandaObjectiveType))
+	assert.NotContains(t, g.availableRegularActions(), DrawObjective)
+
+	// the limit is part of the game's settings
+	g.Settings.ObjectiveHandLimit = 6
+	assert.Contains(t, g.availableRegularActions(), DrawObjective)
+}
+
+func TestAutoPlay(t *testing.T) {
+	turn := Turn{CurrentPrompt: Prompt{
+		Action:     ChooseAction,
+		SelectFrom: []any{"PlaceIrrigation", "EndTurn"},
+		Pid:        "prompt",
+		Gid:        "game",
+	}}
+	r := AutoPlay(turn)
+	assert.Equal(t, "EndTurn", r.Selection)
+	assert.Equal(t, "prompt", r.Pid)
+	assert.Equal(t, "game", r.Gid)
+
+	turn.CurrentPrompt = Prompt{Action: ChooseGrowth, SelectFrom: []any{"p2", "p1"}}
+	r = AutoPlay(turn)
+	assert.Equal(t, "p2", r.Selection)
+}
+
+func TestPromptDeadline(t *testing.T) {
+	issued := time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time { return issued }
+	t.Cleanup(func() {
+		now = time.Now
+	})
+	g := StartGame([]Player{{ID: "a", Improvements: make(ImprovementReserve), Bamboo: make(BambooReserve)}})
+	p := GameFlow(g, PromptResponse{Action: NextPlayerTurn})
+	assert.Equal(t, issued.Add(60*time.Second), p.Deadline)
+	assert.False(t, p.Expired(issued.Add(59*time.Second)))
+	assert.True(t, p.Expired(issued.Add(60*time.Second)))
+
+	// a rejected response re-sends the prompt with the time that is left
+	now = func() time.Time { return issued.Add(45 * time.Second) }
+	resent := GameFlow(g, PromptResponse{Action: ChooseAction, Pid: "stale"})
+	assert.Equal(t, p.Pid, resent.Pid)
+	assert.Equal(t, 15, resent.Time)
+	assert.Equal(t, p.Deadline, resent.Deadline)
+}
+
+func TestTimeBank(t *testing.T) {
+	start := time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+	settings := Settings{TimeBank: 100, Increment: 10, BankExpiry: AutoPlayOnExpiry}
+	g := StartGame([]Player{
+		{ID: "a", Improvements: make(ImprovementReserve), Bamboo: make(BambooReserve)},
+		{ID: "b", Improvements: make(ImprovementReserve), Bamboo: make(BambooReserve)},
+	}, WithSeed(1), WithSettings(settings))
+	first := g.Players[0].ID
+	second := g.Players[1].ID
+
+	p := gameFlow(g, PromptResponse{Action: NextPlayerTurn}, start)
+	assert.Equal(t, 110*time.Second, g.BankRemaining(first, start))
+	// only the current player's bank runs
+	assert.Equal(t, 80*time.Second, g.BankRemaining(first, start.Add(30*time.Second)))
+	assert.Equal(t, 100*time.Second, g.BankRemaining(second, start.Add(30*time.Second)))
+	assert.Equal(t, 60, p.Time)
+
+	// end the turn 30 seconds in, and the first player keeps what was left
+	g.CurrentTurn.CurrentPrompt = Prompt{Action: ChooseAction, SelectFrom: []any{EndTurn}, Pid: "end"}
+	gameFlow(g, PromptResponse{Action: ChooseAction, Selection: EndTurn, Pid: "end"}, start.Add(30*time.Second))
+	assert.Equal(t, second, g.CurrentTurn.PlayerID)
+	assert.Equal(t, 80*time.Second, g.GetPlayer(first).TimeBank)
+	assert.Equal(t, 110*time.Second, g.BankRemaining(second, start.Add(30*time.Second)))
+
+	// a prompt never outlasts the bank
+	g.GetPlayer(second).TimeBank = 5 * time.Second
+	g.CurrentTurn.CurrentPrompt = Prompt{Action: ChooseAction, SelectFrom: []any{CollectIrrigation}, Pid: "short"}
+	p = gameFlow(g, PromptResponse{Action: ChooseAction, Selection: CollectIrrigation, Pid: "short"}, start.Add(31*time.Second))
+	assert.Equal(t, start.Add(35*time.Second), p.Deadline)
+	assert.Equal(t, 4, p.Time)
+	c := g.ClientSafe(first).(ClientGameState)
+	assert.Equal(t, 80, c.Players[0].TimeBank)
+}
+
+func TestTimeBankForfeit(t *testing.T) {
+	start := time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+	settings := Settings{TimeBank: 30, BankExpiry: ForfeitOnExpiry}
+	g := StartGame([]Player{
+		{ID: "a", Improvements: make(ImprovementReserve), Bamboo: make(BambooReserve)},
+		{ID: "b", Improvements: make(ImprovementReserve), Bamboo: make(BambooReserve)},
+		{ID: "c", Improvements: make(ImprovementReserve), Bamboo: make(BambooReserve)},
+	}, WithSeed(1), WithSettings(settings))
+	first := g.Players[0].ID
+	p := gameFlow(g, PromptResponse{Action: NextPlayerTurn}, start)
+	assert.Equal(t, start.Add(30*time.Second), p.Deadline)
+
+	// expiring before the bank is spent only auto plays
+	expire(g, start.Add(10*time.Second))
+	assert.False(t, g.GetPlayer(first).Forfeited)
+
+	expire(g, start.Add(30*time.Second))
+	assert.True(t, g.GetPlayer(first).Forfeited)
+	assert.Equal(t, g.Players[1].ID, g.CurrentTurn.PlayerID)
+
+	// the forfeit is journaled, so a replay forfeits the same player
+	replayed, err := Replay(g.Seed, g.Journal)
+	assert.Nil(t, err)
+	assert.True(t, replayed.GetPlayer(first).Forfeited)
+
+	// forfeited players are skipped, and the game ends when one player is left
+	g.CurrentTurn.CurrentPrompt = Prompt{Action: ChooseAction, SelectFrom: []any{EndTurn}, Pid: "end"}
+	gameFlow(g, PromptResponse{Action: ChooseAction, Selection: EndTurn, Pid: "end"}, start.Add(40*time.Second))
+	assert.Equal(t, g.Players[2].ID, g.CurrentTurn.PlayerID)
+	p = gameFlow(g, PromptResponse{Action: Forfeit, Selection: g.Players[1].ID}, start.Add(50*time.Second))
+	assert.Equal(t, EndGame, p.Action)
+}
+
 func TestProcessPlayerAction(t *testing.T) {
 	// 24 inputs (11 + 8 for choose action + 5 weather die roll outcomes) = 20 tests cases that all require similar setup and logic to run
 	// this test is massive, but it covers about 50% of game.go
@@ -213,7 +471,7 @@ func TestProcessPlayerAction(t *testing.T) {
 
 	// for forcing certain die rolls
 	rollResult := 0
-	roll = func(int) int { return rollResult }
+	roll = func(*rand.Rand, int) int { return rollResult }
 
 	cases := []struct {
 		Name  string
@@ -243,7 +501,8 @@ func TestProcessPlayerAction(t *testing.T) {
 			},
 			NP: ChooseAction,
 			Adhoc: func(tt *testing.T) {
-				assert.Equal(tt, g.Board.Plots["p1"].Bamboo, 1)
+				// p1 grew its first section when it was placed next to the pond
+				assert.Equal(tt, 2, g.Board.Plots["p1"].Bamboo)
 			},
 		},
 		{
@@ -270,7 +529,8 @@ func TestProcessPlayerAction(t *testing.T) {
 			NP: ChooseAction,
 			Adhoc: func(tt *testing.T) {
 				assert.Equal(tt, g.Board.GardenerLocation, "p2")
-				assert.Equal(tt, g.Board.Plots["p2"].Bamboo, 1)
+				assert.Equal(tt, 2, g.Board.Plots["p2"].Bamboo)
+				assert.Contains(tt, g.GameLog[len(g.GameLog)-1].Message, "grew bamboo on p2")
 			},
 		},
 		{
@@ -564,3 +824,95 @@ func TestCompleteObjectives(t *testing.T) {
 	assert.Equal(t, 8, len(g.Players[0].CompleteObjectives))
 	assert.Equal(t, 0, len(g.Players[0].Objectives))
 }
+
+func TestCompletePandaObjectivesSpendBamboo(t *testing.T) {
+	g := NewGame()
+	g.AddPlayers([]Player{{ID: "a", Bamboo: BambooReserve{GreenBambooPlot: 3, YellowBambooPlot: 2}}})
+	g.NextTurn()
+	g.Players[0].Objectives = []Objective{
+		{PandaObjective{GreenCount: 2, Value: 3}},
+		{PandaObjective{GreenCount: 2, Value: 4}},
+		{PandaObjective{YellowCount: 2, Value: 4}},
+	}
+
+	g.CompleteObjectives()
+
+	// the first objective spends 2 of the 3 green, so the second can't also be completed with them
+	assert.Equal(t, 2, len(g.Players[0].CompleteObjectives))
+	assert.Equal(t, 3, g.Players[0].CompleteObjectives[0].Points())
+	assert.Equal(t, 1, len(g.Players[0].Objectives))
+	assert.Equal(t, 4, g.Players[0].Objectives[0].Points())
+	assert.Equal(t, 1, g.Players[0].Bamboo[GreenBambooPlot])
+	assert.Equal(t, 0, g.Players[0].Bamboo[YellowBambooPlot])
+	assert.Equal(t, 38, g.BambooSupply[GreenBambooPlot])
+	assert.Equal(t, 32, g.BambooSupply[YellowBambooPlot])
+}
+
+func TestBambooSupply(t *testing.T) {
+	g := NewGame(WithSettings(Settings{BambooSupply: BambooReserve{GreenBambooPlot: 3, YellowBambooPlot: 1}}))
+	// placing the plots grows their first bamboo from the supply
+	g.Board.AddPlot("p1", GreenBambooPlot, FertilizerImprovement)
+	g.Board.AddPlot("p2", YellowBambooPlot, NoImprovement)
+	assert.Equal(t, 2, g.Board.Plots["p1"].Bamboo)
+	assert.Equal(t, 1, g.Board.Plots["p2"].Bamboo)
+
+	// only 1 green is left, so the fertilizer can't add its extra section
+	assert.Equal(t, 1, g.Board.PlotGrowBamboo("p1"))
+	assert.Equal(t, 0, g.Board.PlotGrowBamboo("p1"))
+	assert.Equal(t, 3, g.Board.Plots["p1"].Bamboo)
+	assert.Equal(t, 0, g.Board.PlotGrowBamboo("p2"))
+	assert.Equal(t, 0, g.BambooSupply[GreenBambooPlot])
+	assert.Equal(t, 0, g.BambooSupply[YellowBambooPlot])
+	// pink was left out of the settings, so it starts with the count from the physical game
+	assert.Equal(t, 24, g.BambooSupply[PinkBambooPlot])
+
+	// the supply is reported to clients
+	g.AddPlayers([]Player{{ID: "a"}})
+	c := g.ClientSafe("a").(ClientGameState)
+	assert.Equal(t, g.BambooSupply, c.BambooSupply)
+
+	// the settings are not used up by the game
+	assert.Equal(t, 3, g.Settings.BambooSupply[GreenBambooPlot])
+}
+
+func TestAddPlayersReserves(t *testing.T) {
+	g := NewGame()
+	g.AddPlayers([]Player{{ID: "a"}, {ID: "b", Bamboo: BambooReserve{GreenBambooPlot: 2}}})
+	for i := range g.Players {
+		p := &g.Players[i]
+		assert.NotNil(t, p.Bamboo)
+		assert.NotNil(t, p.Improvements)
+		if p.ID == "a" {
+			// eating bamboo or taking an improvement doesn't panic for players that joined with only an id
+			p.Bamboo[GreenBambooPlot]++
+			p.Improvements[WatershedImprovement]++
+			assert.Equal(t, 1, p.Bamboo[GreenBambooPlot])
+		} else {
+			// reserves the player came with are kept
+			assert.Equal(t, 2, p.Bamboo[GreenBambooPlot])
+		}
+	}
+}
+
+func TestSeededGameIsReproducible(t *testing.T) {
+	now = func() time.Time { return time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC) }
+	t.Cleanup(func() {
+		now = time.Now
+	})
+	play := func(seed uint64) []byte {
+		g := StartGame([]Player{
+			{ID: "a", Improvements: make(ImprovementReserve), Bamboo: make(BambooReserve)},
+			{ID: "b", Improvements: make(ImprovementReserve), Bamboo: make(BambooReserve)},
+			{ID: "c", Improvements: make(ImprovementReserve), Bamboo: make(BambooReserve)},
+		}, WithSeed(seed))
+		GameFlow(g, PromptResponse{Action: NextPlayerTurn})
+		for i := 0; i < 100 && g.CurrentTurn.CurrentPrompt.Action != EndGame; i++ {
+			GameFlow(g, AutoPlay(g.CurrentTurn))
+		}
+		b, _ := json.Marshal(g)
+		return b
+	}
+
+	assert.Equal(t, play(42), play(42))
+	assert.NotEqual(t, play(42), play(43))
+}
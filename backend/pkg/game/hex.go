@@ -0,0 +1,105 @@
+package game
+
+import (
+	"fmt"
+	"slices"
+)
+
+// axial coordinates of a plot on the board. The pond is at (0, 0)
+type Hex struct {
+	Q int `json:"q"`
+	R int `json:"r"`
+}
+
+// the direction of each edge index, in the same rotational order as Plot.Edges.
+// the neighbor across edge i of a plot is at the plot's coordinates plus hexDirections[i]
+var hexDirections = [6]Hex{
+	{1, 0},
+	{1, -1},
+	{0, -1},
+	{-1, 0},
+	{-1, 1},
+	{0, 1},
+}
+
+func (h Hex) Add(o Hex) Hex {
+	return Hex{h.Q + o.Q, h.R + o.R}
+}
+
+// the coordinates of the neighbor across edge index i
+func (h Hex) Neighbor(i int) Hex {
+	return h.Add(hexDirections[edgeIndex(i)])
+}
+
+// the number of steps between two hexes
+func (h Hex) Distance(o Hex) int {
+	dq := h.Q - o.Q
+	dr := h.R - o.R
+	return (abs(dq) + abs(dr) + abs(dq+dr)) / 2
+}
+
+// the third cube coordinate, for when a calculation is easier with it
+func (h Hex) S() int {
+	return -h.Q - h.R
+}
+
+func (h Hex) String() string {
+	return fmt.Sprintf("(%d, %d)", h.Q, h.R)
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
+
+// the id of the plot at the coordinates, including future plots. empty if there is no plot there
+func (b *Board) PlotIDAt(h Hex) string {
+	return b.coords[h]
+}
+
+// adds a new plot to the board and indexes its coordinates. replacing a plot in place doesn't need this
+func (b *Board) placePlot(p Plot) {
+	b.Plots[p.ID] = p
+	if b.coords == nil {
+		b.coords = make(map[Hex]string)
+	}
+	b.coords[p.Coord] = p.ID
+}
+
+// indexes every plot by its coordinates
+func (b *Board) indexCoords() {
+	b.coords = make(map[Hex]string, len(b.Plots))
+	for pid, p := range b.Plots {
+		b.coords[p.Coord] = pid
+	}
+}
+
+// the ids of placed plots in a straight line from the plot in the direction of edge index i, nearest first.
+// the line stops at the first gap or future plot
+func (b *Board) Line(pid string, i int) []string {
+	line := make([]string, 0)
+	h := b.Plots[pid].Coord
+	for {
+		h = h.Neighbor(i)
+		next := b.PlotIDAt(h)
+		if next == "" || b.Plots[next].Type == FuturePlot {
+			return line
+		}
+		line = append(line, next)
+	}
+}
+
+// the ids of all plots, including future plots, within n steps of the plot, not counting the plot itself. sorted by id
+func (b *Board) PlotsWithin(pid string, n int) []string {
+	center := b.Plots[pid].Coord
+	plotIDs := make([]string, 0)
+	for id, p := range b.Plots {
+		if d := center.Distance(p.Coord); id != pid && d <= n {
+			plotIDs = append(plotIDs, id)
+		}
+	}
+	slices.Sort(plotIDs)
+	return plotIDs
+}
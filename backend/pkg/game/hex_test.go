@@ -0,0 +1,92 @@
+package game
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHexDistance(t *testing.T) {
+	origin := Hex{0, 0}
+	for i := 0; i < 6; i++ {
+		assert.Equal(t, 1, origin.Distance(origin.Neighbor(i)))
+		// stepping across opposite edges returns to where you started
+		assert.Equal(t, origin, origin.Neighbor(i).Neighbor(inverseEdgeIndex(i)))
+	}
+	assert.Equal(t, 0, origin.Distance(origin))
+	assert.Equal(t, 3, Hex{2, -1}.Distance(Hex{-1, 0}))
+	assert.Equal(t, -1, Hex{2, -1}.S())
+}
+
+func TestCoordsMatchEdges(t *testing.T) {
+	b := NewBoard()
+	for _, pid := range []string{"p1", "p2", "p7", "p8", "p3", "p10"} {
+		b.AddPlot(pid, GreenBambooPlot, NoImprovement)
+	}
+	assert.Equal(t, Hex{0, 0}, b.Plots["p0"].Coord)
+	seen := make(map[Hex]string)
+	for pid, p := range b.Plots {
+		// every plot has its own coordinates
+		assert.Empty(t, seen[p.Coord], "%s and %s share %s", pid, seen[p.Coord], p.Coord)
+		seen[p.Coord] = pid
+		assert.Equal(t, pid, b.PlotIDAt(p.Coord))
+		// the edge graph and the coordinates agree on who is next to who
+		for i := 0; i < 6; i++ {
+			if neighbor := b.PlotNeighbor(pid, i); neighbor != nil {
+				assert.Equal(t, p.Coord.Neighbor(i), neighbor.Coord, "neighbor %d of %s", i, pid)
+			}
+		}
+	}
+}
+
+func TestLine(t *testing.T) {
+	b := NewBoard()
+	b.AddPlot("p1", GreenBambooPlot, NoImprovement)
+	b.AddPlot("p2", GreenBambooPlot, NoImprovement)
+	b.AddPlot("p7", GreenBambooPlot, NoImprovement)
+	b.AddPlot("p8", GreenBambooPlot, NoImprovement)
+	// p8 lines up behind p1 or p2, so one line from the pond is 2 plots long
+	longest := 0
+	for i := 0; i < 6; i++ {
+		line := b.Line("p0", i)
+		longest = max(longest, len(line))
+		for n, pid := range line {
+			assert.Equal(t, n+1, b.Plots["p0"].Coord.Distance(b.Plots[pid].Coord))
+		}
+	}
+	assert.Equal(t, 2, longest)
+	// future plots end a line
+	assert.Empty(t, b.Line("p0", 3))
+}
+
+func TestPlotsWithin(t *testing.T) {
+	b := NewBoard()
+	// the pond is surrounded by 6 future plots
+	assert.Equal(t, 6, len(b.PlotsWithin("p0", 1)))
+	assert.Equal(t, []string{"p0", "p2", "p6"}, b.PlotsWithin("p1", 1))
+	assert.Empty(t, b.PlotsWithin("p0", 0))
+}
+
+func TestPlotIDAtAfterDecoding(t *testing.T) {
+	b := NewBoard()
+	b.AddPlot("p1", GreenBambooPlot, NoImprovement)
+	raw, err := json.Marshal(b)
+	assert.Nil(t, err)
+	var decoded Board
+	assert.Nil(t, json.Unmarshal(raw, &decoded))
+	for pid, p := range b.Plots {
+		assert.Equal(t, pid, decoded.PlotIDAt(p.Coord))
+	}
+	// reading doesn't write, so readers can share a board
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			decoded.Line("p0", 0)
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,69 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// swappable so tests can control timestamps
+var now func() time.Time = time.Now
+
+type JournalEntry struct {
+	Step      int            `json:"step"`
+	Response  PromptResponse `json:"response"`
+	Timestamp time.Time      `json:"timestamp"`
+}
+
+// an ordered log of every response accepted by GameFlow
+type Journal struct {
+	// the players as they were given to StartGame, before turn order was shuffled
+	Players  []Player       `json:"players"`
+	Settings Settings       `json:"settings"`
+	Entries  []JournalEntry `json:"entries"`
+}
+
+func (j *Journal) Record(p PromptResponse, t time.Time) {
+	j.Entries = append(j.Entries, JournalEntry{
+		Step:      len(j.Entries),
+		Response:  p,
+		Timestamp: t,
+	})
+}
+
+// returns a copy of the journal with only the first n entries. Replaying it rebuilds the game as it was after n responses
+func (j Journal) UpTo(n int) Journal {
+	n = min(max(n, 0), len(j.Entries))
+	entries := make([]JournalEntry, n)
+	copy(entries, j.Entries[:n])
+	return Journal{
+		Players:  j.Players,
+		Settings: j.Settings,
+		Entries:  entries,
+	}
+}
+
+// rebuilds a game by starting it with the seed and the journal's players, then feeding every journal entry through GameFlow
+func Replay(seed uint64, j Journal) (*GameState, error) {
+	g := StartGame(clonePlayers(j.Players), WithSeed(seed), WithSettings(j.Settings))
+	for _, e := range j.Entries {
+		gameFlow(g, e.Response, e.Timestamp)
+		if len(g.Journal.Entries) != e.Step+1 {
+			return g, fmt.Errorf("journal entry %d (%s) was rejected on replay", e.Step, e.Response.Action)
+		}
+	}
+	return g, nil
+}
+
+// deep copy of players so the journal is not changed as the game is played
+func clonePlayers(ps []Player) []Player {
+	cp := make([]Player, 0)
+	b, err := json.Marshal(ps)
+	if err != nil {
+		panic(err)
+	}
+	if err := json.Unmarshal(b, &cp); err != nil {
+		panic(err)
+	}
+	return cp
+}
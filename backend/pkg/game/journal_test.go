@@ -0,0 +1,83 @@
+package game
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func playAutoGame(seed uint64, steps int) *GameState {
+	g := StartGame([]Player{
+		{ID: "a", Improvements: make(ImprovementReserve), Bamboo: make(BambooReserve)},
+		{ID: "b", Improvements: make(ImprovementReserve), Bamboo: make(BambooReserve)},
+	}, WithSeed(seed))
+	GameFlow(g, PromptResponse{Action: NextPlayerTurn})
+	for i := 0; i < steps && g.CurrentTurn.CurrentPrompt.Action != EndGame; i++ {
+		GameFlow(g, AutoPlay(g.CurrentTurn))
+	}
+	return g
+}
+
+func TestJournalRecordsAcceptedResponses(t *testing.T) {
+	g := playAutoGame(7, 10)
+	assert.Equal(t, 11, len(g.Journal.Entries))
+	for i, e := range g.Journal.Entries {
+		assert.Equal(t, i, e.Step)
+		assert.False(t, e.Timestamp.IsZero())
+	}
+	assert.Equal(t, NextPlayerTurn, g.Journal.Entries[0].Response.Action)
+	// a rejected response is not journaled
+	GameFlow(g, PromptResponse{Action: ChooseGrowth, Pid: "stale"})
+	assert.Equal(t, 11, len(g.Journal.Entries))
+}
+
+func TestReplay(t *testing.T) {
+	g := playAutoGame(11, 40)
+
+	replayed, err := Replay(g.Seed, g.Journal)
+	assert.Nil(t, err)
+	expected, _ := json.Marshal(g)
+	actual, _ := json.Marshal(replayed)
+	assert.Equal(t, expected, actual)
+}
+
+func TestReplayStoredJournal(t *testing.T) {
+	g := playAutoGame(3, 40)
+	// a journal that has been through storage holds raw json values instead of typed selections
+	bb := new(bytes.Buffer)
+	json.NewEncoder(bb).Encode(g)
+	stored := new(GameState)
+	json.NewDecoder(bb).Decode(stored)
+
+	replayed, err := Replay(stored.Seed, stored.Journal)
+	assert.Nil(t, err)
+	assert.Equal(t, g.RandomDraws, replayed.RandomDraws)
+	assert.Equal(t, g.CurrentTurn.CurrentPrompt.Pid, replayed.CurrentTurn.CurrentPrompt.Pid)
+}
+
+func TestReplayUpTo(t *testing.T) {
+	now = func() time.Time { return time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC) }
+	t.Cleanup(func() {
+		now = time.Now
+	})
+	partial := playAutoGame(5, 15)
+	full := playAutoGame(5, 30)
+
+	replayed, err := Replay(full.Seed, full.Journal.UpTo(len(partial.Journal.Entries)))
+	assert.Nil(t, err)
+	expected, _ := json.Marshal(partial)
+	actual, _ := json.Marshal(replayed)
+	assert.Equal(t, expected, actual)
+}
+
+func TestReplayRejectsBadJournal(t *testing.T) {
+	g := playAutoGame(9, 5)
+	j := g.Journal.UpTo(3)
+	j.Entries[2].Response.Pid = "not a prompt"
+
+	_, err := Replay(g.Seed, j)
+	assert.NotNil(t, err)
+}
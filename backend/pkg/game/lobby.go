@@ -0,0 +1,220 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+const MaxPlayers = 4
+
+type Lobby struct {
+	Host       string
+	Players    []string
+	Spectators []string
+	Started    bool
+	GameId     string
+	Settings   Settings
+	Bots       map[string]string // the name of the strategy playing each computer player's seat, by player id
+}
+
+// a request from the host to fill a seat with a computer player
+type BotRequest struct {
+	Gid      string `json:"gameId"`
+	Strategy string `json:"strategy"`
+}
+
+// seats a computer player that plays with the named strategy, and returns its player id
+func (l *Lobby) AddBot(strategy string) (string, error) {
+	if len(l.Players) >= MaxPlayers {
+		return "", errors.New("there are no empty seats")
+	}
+	if l.Bots == nil {
+		l.Bots = make(map[string]string)
+	}
+	id := ""
+	for n := 1; id == "" || l.Bots[id] != ""; n++ {
+		id = fmt.Sprintf("bot:%d", n)
+	}
+	l.Bots[id] = strategy
+	l.Players = append(l.Players, id)
+	return id, nil
+}
+
+func (l Lobby) IsBot(pid string) bool {
+	_, ok := l.Bots[pid]
+	return ok
+}
+
+// a computer player with the named strategy takes over the player's seat, keeping their player id
+func (l *Lobby) HandToBot(pid string, strategy string) {
+	if l.Bots == nil {
+		l.Bots = make(map[string]string)
+	}
+	l.Bots[pid] = strategy
+}
+
+// removes the player from the lobby. a seat handed to a bot stays. when the host leaves,
+// the first remaining person becomes host, preferring players over spectators
+func (l *Lobby) Leave(pid string) {
+	if !l.IsBot(pid) {
+		l.Players = slices.DeleteFunc(l.Players, func(p string) bool { return p == pid })
+	}
+	l.Spectators = slices.DeleteFunc(l.Spectators, func(p string) bool { return p == pid })
+	if l.Host != pid {
+		return
+	}
+	l.Host = ""
+	if people := l.people(); len(people) > 0 {
+		l.Host = people[0]
+	}
+}
+
+// true if the person is in the lobby as a player or a spectator. bots aren't people
+func (l Lobby) Has(pid string) bool {
+	return slices.Contains(l.people(), pid)
+}
+
+// true when no people are left, only bots
+func (l Lobby) Empty() bool {
+	return len(l.people()) == 0
+}
+
+// the players who aren't bots, followed by the spectators
+func (l Lobby) people() []string {
+	people := make([]string, 0, len(l.Players)+len(l.Spectators))
+	for _, p := range l.Players {
+		if !l.IsBot(p) {
+			people = append(people, p)
+		}
+	}
+	return append(people, l.Spectators...)
+}
+
+// what happens when a player's time bank runs out
+type BankExpiry string
+
+const (
+	AutoPlayOnExpiry BankExpiry = "AUTOPLAY" // the rest of the player's turn is auto played
+	ForfeitOnExpiry  BankExpiry = "FORFEIT"  // the player forfeits the game
+)
+
+// what happens to a player's seat when they leave a game in progress
+type Departure string
+
+const (
+	ForfeitOnLeave Departure = "FORFEIT" // the player forfeits and their seat drops out of the turn order
+	BotOnLeave     Departure = "BOT"     // a computer player takes over the seat
+)
+
+// options the host can change in the lobby before the game starts
+type Settings struct {
+	// seconds each player has for the whole game. 0 turns time banks off, leaving only the per prompt timers
+	TimeBank int `json:"timeBank"`
+	// seconds added to a player's time bank at the start of each of their turns
+	Increment int `json:"increment"`
+	// empty auto plays
+	BankExpiry BankExpiry `json:"bankExpiry"`
+	// the most incomplete objectives a player can hold. 0 uses the standard limit
+	ObjectiveHandLimit int `json:"objectiveHandLimit"`
+	// bamboo sections of each color at the start of the game. colors left out use the count from the physical game
+	BambooSupply BambooReserve `json:"bambooSupply"`
+	// empty forfeits
+	OnLeave Departure `json:"onLeave"`
+	// spectators' messages are only shown to other spectators
+	SeparateSpectatorChat bool `json:"separateSpectatorChat"`
+}
+
+// a request from the host to change the lobby's settings
+type SettingsChange struct {
+	Gid      string   `json:"gameId"`
+	Settings Settings `json:"settings"`
+}
+
+const standardHandLimit = 5
+
+// the bamboo sections in the box
+func standardBambooSupply() BambooReserve {
+	return BambooReserve{
+		GreenBambooPlot:  36,
+		YellowBambooPlot: 30,
+		PinkBambooPlot:   24,
+	}
+}
+
+func DefaultSettings() Settings {
+	return Settings{
+		BankExpiry:         AutoPlayOnExpiry,
+		ObjectiveHandLimit: standardHandLimit,
+		BambooSupply:       standardBambooSupply(),
+		OnLeave:            ForfeitOnLeave,
+	}
+}
+
+func (s Settings) Validate() error {
+	if s.TimeBank < 0 {
+		return errors.New("time bank can't be negative")
+	}
+	if s.Increment < 0 {
+		return errors.New("time bank increment can't be negative")
+	}
+	if s.Increment > 0 && s.TimeBank == 0 {
+		return errors.New("time bank increment requires a time bank")
+	}
+	if s.BankExpiry != "" && s.BankExpiry != AutoPlayOnExpiry && s.BankExpiry != ForfeitOnExpiry {
+		return errors.New("time bank expiry must be AUTOPLAY or FORFEIT")
+	}
+	if s.OnLeave != "" && s.OnLeave != ForfeitOnLeave && s.OnLeave != BotOnLeave {
+		return errors.New("leaving must be FORFEIT or BOT")
+	}
+	if s.ObjectiveHandLimit < 0 {
+		return errors.New("objective hand limit can't be negative")
+	}
+	for pt, n := range s.BambooSupply {
+		if pt != GreenBambooPlot && pt != YellowBambooPlot && pt != PinkBambooPlot {
+			return fmt.Errorf("%s is not a bamboo color", pt)
+		}
+		if n < 0 {
+			return errors.New("bamboo supply can't be negative")
+		}
+	}
+	return nil
+}
+
+// true if players are playing against a time bank
+func (s Settings) TimeBanks() bool {
+	return s.TimeBank > 0
+}
+
+func (s Settings) handLimit() int {
+	if s.ObjectiveHandLimit == 0 {
+		return standardHandLimit
+	}
+	return s.ObjectiveHandLimit
+}
+
+// a copy of the starting supply, so the game can use it up without changing the settings.
+// colors the settings leave out start with the count from the physical game
+func (s Settings) bambooSupply() BambooReserve {
+	supply := standardBambooSupply()
+	for pt, n := range s.BambooSupply {
+		supply[pt] = n
+	}
+	return supply
+}
+
+func (s Settings) timeBank() time.Duration {
+	return time.Duration(s.TimeBank) * time.Second
+}
+
+func (s Settings) increment() time.Duration {
+	return time.Duration(s.Increment) * time.Second
+}
+
+// use with NewGame or StartGame to play with the lobby's settings
+func WithSettings(s Settings) func(*GameState) {
+	return func(g *GameState) {
+		g.Settings = s
+	}
+}
@@ -0,0 +1,84 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLobbyAddBot(t *testing.T) {
+	l := Lobby{Host: "a", Players: []string{"a"}}
+	id, err := l.AddBot("greedy")
+	assert.Nil(t, err)
+	assert.Equal(t, "bot:1", id)
+	id, _ = l.AddBot("random")
+	assert.Equal(t, "bot:2", id)
+	assert.Equal(t, []string{"a", "bot:1", "bot:2"}, l.Players)
+	assert.True(t, l.IsBot("bot:2"))
+	assert.False(t, l.IsBot("a"))
+
+	l.AddBot("random")
+	_, err = l.AddBot("random")
+	assert.NotNil(t, err)
+	assert.Equal(t, MaxPlayers, len(l.Players))
+}
+
+func TestLobbyLeave(t *testing.T) {
+	l := Lobby{Host: "a", Players: []string{"a", "b"}, Spectators: []string{"c"}}
+	l.AddBot("greedy")
+
+	l.Leave("c")
+	assert.Equal(t, []string{}, l.Spectators)
+	assert.Equal(t, "a", l.Host)
+
+	// the host passes to the next person, never a bot
+	l.Spectators = append(l.Spectators, "c")
+	l.Leave("a")
+	assert.Equal(t, []string{"b", "bot:1"}, l.Players)
+	assert.Equal(t, "b", l.Host)
+
+	// a seat handed to a bot stays in the game
+	l.HandToBot("b", "random")
+	l.Leave("b")
+	assert.Equal(t, []string{"b", "bot:1"}, l.Players)
+	assert.Equal(t, "c", l.Host)
+	assert.False(t, l.Empty())
+	assert.True(t, l.Has("c"))
+	assert.False(t, l.Has("b"), "bots aren't people")
+
+	l.Leave("c")
+	assert.Equal(t, "", l.Host)
+	assert.True(t, l.Empty())
+}
+
+func TestSettingsOnLeave(t *testing.T) {
+	s := DefaultSettings()
+	assert.Equal(t, ForfeitOnLeave, s.OnLeave)
+	s.OnLeave = BotOnLeave
+	assert.Nil(t, s.Validate())
+	s.OnLeave = ""
+	assert.Nil(t, s.Validate())
+	s.OnLeave = "VANISH"
+	assert.NotNil(t, s.Validate())
+}
+
+func TestSettingsBankExpiry(t *testing.T) {
+	// a change that leaves the expiry out is the same as auto playing
+	s := Settings{TimeBank: 300, Increment: 5}
+	assert.Nil(t, s.Validate())
+	s.BankExpiry = ForfeitOnExpiry
+	assert.Nil(t, s.Validate())
+	s.BankExpiry = "PAUSE"
+	assert.NotNil(t, s.Validate())
+}
+
+func TestPartialBambooSupply(t *testing.T) {
+	s := Settings{BambooSupply: BambooReserve{GreenBambooPlot: 10}}
+	assert.Nil(t, s.Validate())
+	// only green was changed. the other colors can still grow
+	assert.Equal(t, BambooReserve{GreenBambooPlot: 10, YellowBambooPlot: 30, PinkBambooPlot: 24}, s.bambooSupply())
+	assert.Equal(t, standardBambooSupply(), Settings{}.bambooSupply())
+	// a color can still be left out of the game on purpose
+	s.BambooSupply[PinkBambooPlot] = 0
+	assert.Equal(t, 0, s.bambooSupply()[PinkBambooPlot])
+}
@@ -2,6 +2,8 @@ package game
 
 import (
 	"encoding/json"
+	"slices"
+	"time"
 )
 
 type Player struct {
@@ -20,6 +22,10 @@ type Player struct {
 	Objectives []Objective `json:"objectives"` // TODO: when sending to UI, share number of objectives and types, but not secret info (value, goal)
 	// Objectives in the player's possession that have been completed
 	CompleteObjectives []Objective `json:"completeObjectives"`
+	// time left for the rest of the game, when playing with time banks
+	TimeBank time.Duration `json:"timeBank"`
+	// a forfeited player takes no more turns and ranks last
+	Forfeited bool `json:"forfeited"`
 }
 
 type ClientPlayer struct {
@@ -31,6 +37,8 @@ type ClientPlayer struct {
 	Objectives         []Objective           `json:"objectives,omitempty"`
 	HiddenObjectives   map[ObjectiveType]int `json:"hiddenObjectives,omitempty"`
 	CompleteObjectives []Objective           `json:"completeObjectives"`
+	TimeBank           int                   `json:"timeBank"` // seconds left in the player's time bank
+	Forfeited          bool                  `json:"forfeited"`
 }
 
 func (p Player) ClientSafe(recipient string) ClientPlayer {
@@ -41,6 +49,7 @@ func (p Player) ClientSafe(recipient string) ClientPlayer {
 		Bamboo:             p.Bamboo,
 		Improvements:       p.Improvements,
 		CompleteObjectives: p.CompleteObjectives,
+		Forfeited:          p.Forfeited,
 	}
 
 	if p.ID == recipient {
@@ -75,6 +84,7 @@ func (i ImprovementReserve) AvailableImprovements() []ImprovementType {
 			s = append(s, k)
 		}
 	}
+	slices.Sort(s)
 	return s
 }
 
@@ -134,6 +144,12 @@ func (o *Objective) UnmarshalJSON(b []byte) error {
 			return err
 		}
 		o.ObjectiveChecker = *ob
+	case EmperorObjectiveType:
+		ob := new(EmperorObjective)
+		if err := json.Unmarshal(b, ob); err != nil {
+			return err
+		}
+		o.ObjectiveChecker = *ob
 	}
 	return nil
 }
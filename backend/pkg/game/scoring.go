@@ -0,0 +1,111 @@
+package game
+
+import "slices"
+
+// the final standing of one player when the game ends
+type PlayerResult struct {
+	PlayerID string `json:"playerId"`
+	Name     string `json:"name"`
+	// total points from all completed objectives, including the emperor
+	Score int `json:"score"`
+	// points from completed panda objectives. used to break ties
+	PandaScore int `json:"pandaScore"`
+	// number of completed objectives, not counting the emperor
+	ObjectivesCompleted int  `json:"objectivesCompleted"`
+	Emperor             bool `json:"emperor"`
+	// forfeited players rank below everyone who finished the game
+	Forfeited bool `json:"forfeited"`
+	// 1 is first place. players that are still tied after the tie-break share a rank
+	Rank int `json:"rank"`
+}
+
+type GameResults struct {
+	// every player, ordered from first place to last
+	Standings []PlayerResult `json:"standings"`
+	// ids of the players that ranked first. more than one winner is a shared victory
+	Winners []string `json:"winners"`
+}
+
+// the sum of all the points from the player's completed objectives
+func (p Player) Score() int {
+	score := 0
+	for _, o := range p.CompleteObjectives {
+		if o.ObjectiveChecker == nil {
+			continue
+		}
+		score += o.Points()
+	}
+	return score
+}
+
+// the sum of points from completed panda objectives only
+func (p Player) PandaScore() int {
+	score := 0
+	for _, o := range p.CompleteObjectives {
+		if o.ObjectiveChecker == nil || o.Type() != PandaObjectiveType {
+			continue
+		}
+		score += o.Points()
+	}
+	return score
+}
+
+// scores every player and ranks them. Players who forfeited rank last.
+// The highest score wins. If scores are tied, the player with more points from panda objectives wins.
+// If players are still tied, they share the rank.
+func (g *GameState) Results() GameResults {
+	standings := make([]PlayerResult, len(g.Players))
+	for i, p := range g.Players {
+		completed := 0
+		emperor := false
+		for _, o := range p.CompleteObjectives {
+			if o.ObjectiveChecker != nil && o.Type() == EmperorObjectiveType {
+				emperor = true
+				continue
+			}
+			completed++
+		}
+		standings[i] = PlayerResult{
+			PlayerID:            p.ID,
+			Name:                p.Name,
+			Score:               p.Score(),
+			PandaScore:          p.PandaScore(),
+			ObjectivesCompleted: completed,
+			Emperor:             emperor,
+			Forfeited:           p.Forfeited,
+		}
+	}
+	// stable sort keeps turn order for players that share a rank
+	slices.SortStableFunc(standings, compareResults)
+
+	winners := make([]string, 0)
+	for i := range standings {
+		if i > 0 && compareResults(standings[i-1], standings[i]) == 0 {
+			standings[i].Rank = standings[i-1].Rank
+		} else {
+			standings[i].Rank = i + 1
+		}
+		if standings[i].Rank == 1 {
+			winners = append(winners, standings[i].PlayerID)
+		}
+	}
+
+	return GameResults{
+		Standings: standings,
+		Winners:   winners,
+	}
+}
+
+// orders results from best to worst
+func compareResults(a, b PlayerResult) int {
+	if a.Forfeited != b.Forfeited {
+		if a.Forfeited {
+			return 1
+		}
+		return -1
+	}
+	if a.Score != b.Score {
+		return b.Score - a.Score
+	}
+	return b.PandaScore - a.PandaScore
+}
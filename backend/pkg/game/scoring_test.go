@@ -0,0 +1,88 @@
+package game
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlayerScore(t *testing.T) {
+	p := Player{
+		CompleteObjectives: []Objective{
+			{PandaObjective{Value: 3}},
+			{PandaObjective{Value: 5}},
+			{PlotObjective{Value: 2}},
+			{GardenerObjective{Value: 6}},
+			{EmperorObjective{Value: 2}},
+		},
+	}
+	assert.Equal(t, 18, p.Score())
+	assert.Equal(t, 8, p.PandaScore())
+}
+
+func TestResults(t *testing.T) {
+	g := NewGame()
+	g.Players = []Player{
+		{ID: "a", CompleteObjectives: []Objective{{PlotObjective{Value: 4}}, {PandaObjective{Value: 3}}}},
+		{ID: "b", CompleteObjectives: []Objective{{GardenerObjective{Value: 5}}, {EmperorObjective{Value: 2}}, {PandaObjective{Value: 3}}}},
+		{ID: "c", CompleteObjectives: []Objective{{PlotObjective{Value: 3}}, {PandaObjective{Value: 4}}}},
+		{ID: "d", CompleteObjectives: []Objective{{PlotObjective{Value: 4}}, {PandaObjective{Value: 3}}}},
+	}
+
+	r := g.Results()
+
+	assert.Equal(t, []string{"b"}, r.Winners)
+	assert.Equal(t, 10, r.Standings[0].Score)
+	assert.True(t, r.Standings[0].Emperor)
+	assert.Equal(t, 2, r.Standings[0].ObjectivesCompleted)
+	// c ties a and d on points, but wins the tie-break with more panda points
+	assert.Equal(t, "c", r.Standings[1].PlayerID)
+	assert.Equal(t, 2, r.Standings[1].Rank)
+	// a and d are tied on points and panda points, so they share third in turn order
+	assert.Equal(t, "a", r.Standings[2].PlayerID)
+	assert.Equal(t, "d", r.Standings[3].PlayerID)
+	assert.Equal(t, 3, r.Standings[2].Rank)
+	assert.Equal(t, 3, r.Standings[3].Rank)
+}
+
+func TestResultsSharedVictory(t *testing.T) {
+	g := NewGame()
+	g.Players = []Player{
+		{ID: "a", CompleteObjectives: []Objective{{PandaObjective{Value: 3}}}},
+		{ID: "b", CompleteObjectives: []Objective{{PandaObjective{Value: 3}}}},
+	}
+
+	r := g.Results()
+
+	assert.Equal(t, []string{"a", "b"}, r.Winners)
+	assert.Equal(t, 1, r.Standings[1].Rank)
+}
+
+func TestMarshalEmperorObjective(t *testing.T) {
+	p := Player{
+		ID:                 "emperor",
+		CompleteObjectives: []Objective{{EmperorObjective{Value: 2, OT: EmperorObjectiveType}}},
+	}
+	bb := new(bytes.Buffer)
+	json.NewEncoder(bb).Encode(p)
+	p2 := new(Player)
+	json.NewDecoder(bb).Decode(p2)
+	assert.Equal(t, 2, p2.Score())
+}
+
+func TestResultsForfeitRanksLast(t *testing.T) {
+	g := NewGame()
+	g.Players = []Player{
+		{ID: "a", Forfeited: true, CompleteObjectives: []Objective{{PlotObjective{Value: 9}}}},
+		{ID: "b", CompleteObjectives: []Objective{{PandaObjective{Value: 3}}}},
+	}
+
+	r := g.Results()
+
+	assert.Equal(t, []string{"b"}, r.Winners)
+	assert.Equal(t, "a", r.Standings[1].PlayerID)
+	assert.True(t, r.Standings[1].Forfeited)
+	assert.Equal(t, 2, r.Standings[1].Rank)
+}
@@ -0,0 +1,301 @@
+package game
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ActionType string
+
+const (
+	PlacePlot         ActionType = "PlacePlot"
+	CollectIrrigation ActionType = "CollectIrrigation"
+	MovePanda         ActionType = "MovePanda"
+	MoveGardener      ActionType = "MoveGardener"
+	DrawObjective     ActionType = "DrawObjective"
+	PlaceIrrigation   ActionType = "PlaceIrrigation"
+	PlaceImprovement  ActionType = "PlaceImprovement"
+	EndTurn           ActionType = "EndTurn"
+)
+
+func (p *ActionType) UnmarshalText(b []byte) error {
+	*p = ActionType(string(b))
+	return nil
+}
+
+type PromptType string
+
+const (
+	RollDie                      PromptType = "RollDie"
+	ChooseWeather                PromptType = "ChooseWeather"
+	ChooseImprovementToUse       PromptType = "ChooseImprovementToUse"
+	ChooseImprovementToStash     PromptType = "ChooseImprovementToStash"
+	ChooseGrowth                 PromptType = "ChooseGrowth"
+	ChoosePandaDestination       PromptType = "ChoosePandaDestination"
+	ChooseAction                 PromptType = "ChooseAction"
+	ChoosePlot                   PromptType = "ChoosePlot"
+	ChoosePlotDestination        PromptType = "ChoosePlotDestination"
+	ChooseGardenerDestination    PromptType = "ChooseGardenerDestination"
+	ChooseObjectiveType          PromptType = "ChooseObjectiveType"
+	ChooseIrrigationDestination  PromptType = "ChooseIrrigationDestination"
+	ChooseImprovementDestination PromptType = "ChooseImprovementDestination"
+	NextPlayerTurn               PromptType = "NextPlayerTurn" // internal only.
+	EndGame                      PromptType = "EndGame"        // internal only
+	Forfeit                      PromptType = "Forfeit"        // internal only. the selection is the id of the player leaving the game
+)
+
+func (p *PromptType) UnmarshalText(b []byte) error {
+	*p = PromptType(string(b))
+	return nil
+}
+
+type SelectType string
+
+const (
+	RollSelectType        SelectType = "RollDie"
+	ActionSelectType      SelectType = "ActionType"
+	ImprovementSelectType SelectType = "ImprovementType"
+	ObjectiveSelectType   SelectType = "ObjectiveType"
+	PlotSelectType        SelectType = "PlotType"
+	WeatherSelectType     SelectType = "WeatherType"
+	EdgeIDSelectType      SelectType = "EdgeId"
+	PlotIDSelectType      SelectType = "PlotId"
+)
+
+func (p *SelectType) UnmarshalText(b []byte) error {
+	*p = SelectType(string(b))
+	return nil
+}
+
+type Prompt struct {
+	Action     PromptType `json:"action"`
+	SelectType SelectType `json:"selectType"`
+	SelectFrom []any      `json:"selectFrom"`
+	Time       int        `json:"time"`     // the number of seconds the player has to respond
+	Deadline   time.Time  `json:"deadline"` // when the prompt expires. set by GameFlow when the prompt is issued
+	Pid        string     `json:"playerId"`
+	Gid        string     `json:"gameId"`
+}
+
+// true if the prompt has a deadline that has passed at time t
+func (p Prompt) Expired(t time.Time) bool {
+	return !p.Deadline.IsZero() && !t.Before(p.Deadline)
+}
+
+// the prompt with Time reduced to the number of whole seconds left before the deadline, for re-sending the prompt
+func (p Prompt) Remaining(t time.Time) Prompt {
+	if p.Deadline.IsZero() {
+		return p
+	}
+	left := p.Deadline.Sub(t)
+	p.Time = max(int(math.Ceil(left.Seconds())), 0)
+	return p
+}
+
+type PromptResponse struct {
+	Action    PromptType `json:"action"`
+	Selection any        `json:"selection"`
+	Pid       string     `json:"playerId"`
+	Gid       string     `json:"gameId"`
+}
+
+// given the type of prompt, perform some type conversion so the returned value can be directly asserted to the desired type
+// selections may already be typed (from a prompt in memory) or raw json values (from a prompt that was stored or sent by a client)
+// TODO: can make this generic
+func GetSelection(pt PromptType, s any) any {
+	switch pt {
+	case ChooseAction:
+		// return ActionType
+		return ActionType(fmt.Sprint(s))
+	case ChooseObjectiveType:
+		// convert to objectivetype
+		return ObjectiveType(fmt.Sprint(s))
+	case ChooseWeather:
+		//convert to weathertype
+		return WeatherType(fmt.Sprint(s))
+	case ChooseImprovementToUse, ChooseImprovementToStash:
+		// convert to ImprovementType
+		return ImprovementType(fmt.Sprint(s))
+	case RollDie:
+		return PromptType(fmt.Sprint(s))
+	case ChoosePlot:
+		// convert to DeckPlot
+		if dp, ok := s.(DeckPlot); ok {
+			return dp
+		}
+		m, _ := s.(map[string]any)
+		dp := DeckPlot{
+			Type:        PlotType(mapString(m, "type", "Type")),
+			Improvement: ImprovementType(mapString(m, "improvement", "Improvement")),
+		}
+		return dp
+	default: // includes: ChooseGardenerDestination, ChooseImprovementDestination, ChooseIrrigationDestination, ChoosePandaDestination, ChoosePlotDestination, ChooseGrowth (plotIds and edgeIds)
+		return s
+	}
+}
+
+// returns the first string value found in m under any of the keys
+func mapString(m map[string]any, keys ...string) string {
+	for _, k := range keys {
+		if v, ok := m[k].(string); ok {
+			return v
+		}
+	}
+	return ""
+}
+
+func NewPromptID() string {
+	return uuid.NewString()
+}
+
+func ConvertToInterfaceSlice[T any](a []T) []any {
+	s := make([]any, len(a))
+	for i, v := range a {
+		s[i] = v
+	}
+	return s
+}
+
+// if a prompt times out, use this for the game system to make an action and advance the game.
+// A player who has timed out ends their turn as soon as they are allowed to, otherwise the first option is taken
+func AutoPlay(t Turn) PromptResponse {
+	r := PromptResponse{
+		Action: t.CurrentPrompt.Action,
+		Pid:    t.CurrentPrompt.Pid,
+		Gid:    t.CurrentPrompt.Gid,
+	}
+	options := t.CurrentPrompt.SelectFrom
+	if len(options) == 0 {
+		return r
+	}
+	r.Selection = options[0]
+	if t.CurrentPrompt.Action == ChooseAction {
+		for _, o := range options {
+			if GetSelection(ChooseAction, o) == EndTurn {
+				r.Selection = o
+			}
+		}
+	}
+	return r
+}
+
+func StartGame(players []Player, cfgs ...func(*GameState)) *GameState {
+	g := NewGame(cfgs...)
+	g.Journal = Journal{
+		Players:  clonePlayers(players),
+		Settings: g.Settings,
+		Entries:  make([]JournalEntry, 0),
+	}
+	g.AddPlayers(players)
+	for i := range g.Players {
+		g.Players[i].TimeBank = g.Settings.timeBank()
+	}
+	g.DealObjectives()
+	return g
+}
+
+func GameFlow(g *GameState, p PromptResponse) Prompt {
+	return gameFlow(g, p, now())
+}
+
+// advances the game with a response made at time t
+func gameFlow(g *GameState, p PromptResponse, t time.Time) Prompt {
+	// the link between the board and the supply is lost when the game is stored
+	g.Board.SetBambooSupply(g.BambooSupply)
+	var prompt Prompt
+	if p.Action == Forfeit {
+		// a player can forfeit at any time, not only on their turn
+		pid, _ := p.Selection.(string)
+		player := g.GetPlayer(pid)
+		if player == nil || player.Forfeited {
+			return g.CurrentTurn.CurrentPrompt.Remaining(t)
+		}
+		g.Journal.Record(p, t)
+		player.Forfeited = true
+		g.logf("%s forfeited", player.Name)
+		if pid != g.CurrentTurn.PlayerID && !g.lastPlayerStanding() {
+			return g.CurrentTurn.CurrentPrompt.Remaining(t)
+		}
+		prompt = Prompt{Action: NextPlayerTurn}
+	} else {
+		if err := g.ValidatePlayerAction(p); err != nil {
+			// re-send prompt with the time that is left
+			return g.CurrentTurn.CurrentPrompt.Remaining(t)
+		}
+		g.Journal.Record(p, t)
+		if len(g.CurrentTurn.CurrentPrompt.SelectFrom) == 0 && p.Action != NextPlayerTurn {
+			// there was nothing to choose, so the step is skipped
+			prompt = g.NextChooseActionPrompt()
+		} else {
+			prompt = g.ProcessPlayerAction(p)
+		}
+		// complete objectives based on what the player just did
+		g.CompleteObjectives()
+	}
+	if prompt.Action == NextPlayerTurn {
+		g.chargeTimeBank(t)
+		if !g.lastPlayerStanding() {
+			g.NextTurn()
+		}
+		if g.lastPlayerStanding() || g.GetCurrentPlayer().ID == g.EmperorWinner {
+			// when turn circles back to emperor winner, or everyone else has forfeited, the game ends
+			g.CurrentTurn.CurrentPrompt = Prompt{
+				Action: EndGame,
+			}
+			return g.CurrentTurn.CurrentPrompt
+		}
+		g.startTurn(t)
+		// complete objectives at the beginning of a player's turn if other player's actions completed for them
+		g.CompleteObjectives()
+		// there is no weather on the first round
+		if g.TurnCounter.Round != 1 {
+			prompt = Prompt{
+				Action:     RollDie,
+				SelectType: RollSelectType,
+				SelectFrom: []any{
+					RollDie,
+				},
+				Time: 10,
+				Pid:  g.nextPromptID(),
+			}
+		} else {
+			prompt = Prompt{
+				Action:     ChooseAction,
+				SelectType: ActionSelectType,
+				SelectFrom: ConvertToInterfaceSlice(g.availableRegularActions()),
+				Time:       60,
+				Pid:        g.nextPromptID(),
+			}
+		}
+	}
+	if prompt.Time > 0 {
+		prompt.Deadline = t.Add(time.Duration(prompt.Time) * time.Second)
+		if g.Settings.TimeBanks() {
+			// the prompt can't outlast the player's time bank
+			bankDeadline := t.Add(g.BankRemaining(g.CurrentTurn.PlayerID, t))
+			if bankDeadline.Before(prompt.Deadline) {
+				prompt.Deadline = bankDeadline
+				prompt = prompt.Remaining(t)
+			}
+		}
+	}
+	g.CurrentTurn.CurrentPrompt = prompt
+	return prompt
+}
+
+// call when the current prompt's deadline passes. The game plays for the player,
+// unless their time bank is spent and the settings say they forfeit
+func Expire(g *GameState) Prompt {
+	return expire(g, now())
+}
+
+func expire(g *GameState, t time.Time) Prompt {
+	pid := g.CurrentTurn.PlayerID
+	if g.Settings.TimeBanks() && g.Settings.BankExpiry == ForfeitOnExpiry && g.BankRemaining(pid, t) <= 0 {
+		return gameFlow(g, PromptResponse{Action: Forfeit, Selection: pid, Gid: g.CurrentTurn.CurrentPrompt.Gid}, t)
+	}
+	return gameFlow(g, AutoPlay(g.CurrentTurn), t)
+}
@@ -1,4 +1,8 @@
-package engine
+// Package protocol is the envelope and event names of the json websocket protocol, shared by the server and its clients.
+// it only depends on the standard library, so clients can use it without linking the server
+package protocol
+
+import "encoding/json"
 
 type ServerEventType string
 
@@ -10,6 +14,8 @@ const (
 	ActionPrompt ServerEventType = "ActionPrompt"
 	Goodbye      ServerEventType = "Goodbye" // the server has forced the connection closed
 	Warning      ServerEventType = "Warning" // the last message received was bad. Warn the client to do better
+	ChatMessage  ServerEventType = "ChatMessage"
+	ChatHistory  ServerEventType = "ChatHistory" // a page of earlier chat, for whoever asked for it
 )
 
 type ClientEventType string
@@ -18,11 +24,25 @@ const (
 	JoinGame        ClientEventType = "JoinGame"
 	LeaveGame       ClientEventType = "LeaveGame"
 	GameChat        ClientEventType = "GameChat"
+	FetchChat       ClientEventType = "FetchChat"
 	TakeAction      ClientEventType = "TakeAction"
 	Reprompt        ClientEventType = "RePrompt"
 	CreateGame      ClientEventType = "CreateGame"
 	StartGame       ClientEventType = "StartGame"
 	ChangeSettings  ClientEventType = "ChangeSettings"
+	AddBot          ClientEventType = "AddBot"
 	Matchmake       ClientEventType = "Matchmake"
 	CancelMatchmake ClientEventType = "CancelMatchmake"
 )
+
+// what clients send. the message is decoded once the type is known
+type ClientEventShell struct {
+	MessageType string          `json:"messageType"`
+	Message     json.RawMessage `json:"message"`
+}
+
+// what the server sends
+type ServerEventShell struct {
+	MessageType string `json:"messageType"`
+	Message     any    `json:"message"`
+}